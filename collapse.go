@@ -0,0 +1,87 @@
+package timber
+
+import (
+	"reflect"
+	"time"
+)
+
+// Key in LogRecord.Extra holding the number of identical records a logger
+// with ConfigLogger.Collapse held back
+const RepeatedExtra = "repeated"
+
+// Holds back records that repeat the last record written by a logger
+// within its ConfigLogger.Collapse window, like syslogd's "last message
+// repeated N times".  The first record is written straight away; the
+// repeats are counted and reported by a single copy of the last one with
+// RepeatedExtra set, written when a different record arrives or the window
+// ends.
+//
+// Only used by the delivery goroutine of a logger (or with the sync mutex
+// held).
+type collapser struct {
+	last     *LogRecord // last record written
+	held     *LogRecord // last repeat held back
+	repeated int
+	deadline time.Time   // end of the window started by last, by record time
+	timer    *time.Timer // running while records are held back
+}
+
+// Same level, call site, message and Extra, so that records only told
+// apart by their fields (e.g. from the ...KV methods) are all written
+func sameRecord(a, b *LogRecord) bool {
+	return a.Level == b.Level && a.SourceLine == b.SourceLine && a.SourceFile == b.SourceFile &&
+		a.Message == b.Message && reflect.DeepEqual(a.Extra, b.Extra)
+}
+
+// Writes rec unless it repeats the last record inside the window.  The
+// window is measured with the record timestamps, so records delivered late
+// from a backed up queue are still compared by when they were logged.
+// startTimer is called with the wall clock time left in the window the
+// first time a record is held back, to write the count at its end.
+func (c *collapser) write(cfg *ConfigLogger, rec *LogRecord, startTimer func(time.Duration) *time.Timer) {
+	at := rec.Timestamp
+	if at.IsZero() {
+		// slog records may have no time
+		at = time.Now()
+	}
+	if cfg.Collapse > 0 && c.last != nil && at.Before(c.deadline) && sameRecord(c.last, rec) {
+		c.held = rec
+		c.repeated++
+		if c.timer == nil {
+			c.timer = startTimer(time.Until(c.deadline))
+		}
+		return
+	}
+	c.flush(cfg)
+	writeRecord(cfg, rec)
+	if cfg.Collapse > 0 {
+		c.last = rec
+		c.deadline = at.Add(cfg.Collapse)
+	}
+}
+
+// Writes the count of any records held back and starts over
+func (c *collapser) flush(cfg *ConfigLogger) {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if c.repeated > 0 {
+		writeRecord(cfg, repeatedRecord(c.held, c.repeated))
+	}
+	c.last, c.held, c.repeated = nil, nil, 0
+}
+
+// The timer channel, or nil (blocking forever) if no records are held
+func (c *collapser) timeout() <-chan time.Time {
+	if c.timer == nil {
+		return nil
+	}
+	return c.timer.C
+}
+
+func repeatedRecord(rec *LogRecord, n int) *LogRecord {
+	summary := *rec
+	summary.Extra = mergeExtra(rec.Extra, map[string]interface{}{RepeatedExtra: n})
+	return &summary
+}
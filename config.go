@@ -1,25 +1,59 @@
 package timber
 
 import (
+	"fmt"
 	"log"
 	"path"
+	"strings"
 )
 
-func (t *Timber) LoadConfig(filename string) {
+// Loads an XML or JSON config file, picked by its extension.  Nothing is
+// changed unless the whole file is valid.
+func (t *Timber) LoadConfig(filename string) error {
 	if len(filename) <= 0 {
-		return
+		return fmt.Errorf("Empty filename")
 	}
-	ext := path.Ext(filename)
-	ext = ext[1:]
+	ext := strings.TrimPrefix(path.Ext(filename), ".")
 
 	switch ext {
 	case "xml":
-		t.LoadXMLConfig(filename)
-		break
+		return t.LoadXMLConfig(filename)
 	case "json":
-		t.LoadJSONConfig(filename)
-		break
+		return t.LoadJSONConfig(filename)
 	default:
-		log.Printf("TIMBER! Unknown config file type %v, only XML and JSON are supported types\n", ext)
+		return fmt.Errorf("TIMBER! Unknown config file type %v, only XML and JSON are supported types", ext)
 	}
 }
+
+// The package level Load functions have nobody to return errors to
+func printConfigError(err error) {
+	if err != nil {
+		log.Printf("%v\n", err)
+	}
+}
+
+// Installs what a config file describes, once all of it has been read
+func (t *Timber) applyConfig(loggers []ConfigLogger, redactor *Redactor) {
+	if redactor != nil {
+		t.setConfigRedactor(redactor)
+	}
+	for _, configLogger := range loggers {
+		t.AddLogger(configLogger)
+	}
+}
+
+// Closes the writers already opened for a config file that failed to load
+func closeConfigWriters(loggers []ConfigLogger) {
+	for _, configLogger := range loggers {
+		configLogger.LogWriter.Close()
+	}
+}
+
+// The level of a filter in a config file.  A filter without a level has
+// no threshold.
+func parseConfigLevel(lvl string) (Level, error) {
+	if strings.TrimSpace(lvl) == "" {
+		return NONE, nil
+	}
+	return ParseLevel(lvl)
+}
@@ -25,13 +25,45 @@ type JSONFilter struct {
 	Tag        string
 	Type       string
 	Level      string
+	MaxLevel   string
 	Format     JSONProperty
 	Properties []JSONProperty
 	Granulars  []JSONGranular
+	Match      *JSONMatch
+}
+
+// Predicates on the records a filter writes, see RecordFilter.  They must
+// all match, or any of them if Any is set; nested matches combine theirs
+// the same way.  Messages and NotMessages are regular expressions, Sources
+// and Hostnames glob patterns.
+type JSONMatch struct {
+	Any         bool
+	Messages    []string
+	NotMessages []string
+	Extras      []JSONExtra
+	Sources     []string
+	Hostnames   []string
+	Matches     []JSONMatch
+}
+
+// Matches records with the Extra key, or with that value if it is set
+type JSONExtra struct {
+	Key   string
+	Value *string
+}
+
+// Masks secrets in every record, see Redactor.  Rules are the names of
+// RedactRules, patterns are regular expressions
+type JSONRedact struct {
+	Keys     []string
+	Rules    []string
+	Patterns []string
+	Mask     string
 }
 
 type JSONConfig struct {
 	Filters []JSONFilter
+	Redact  *JSONRedact
 }
 
 // Loads the configuration from an JSON file (as you were probably expecting)
@@ -52,42 +84,90 @@ func (t *Timber) LoadJSONConfig(filename string) error {
 		return fmt.Errorf("TIMBER! Can't parse json config file: %s %v", filename, err)
 	}
 
+	var redactor *Redactor
+	if r := config.Redact; r != nil {
+		if redactor, err = newConfigRedactor(r.Keys, r.Rules, r.Patterns, r.Mask); err != nil {
+			return err
+		}
+	}
+
+	var loggers []ConfigLogger
 	for _, filter := range config.Filters {
 		if !filter.Enabled {
 			continue
 		}
-		level := GetLevel(filter.Level)
-		formatter := getJSONFormatter(filter)
+		configLogger, err := newJSONConfigLogger(filter)
 		if err != nil {
+			closeConfigWriters(loggers)
 			return err
 		}
-		granulars := make(map[string]Level)
-		for _, granular := range filter.Granulars {
-			granulars[granular.Path] = GetLevel(granular.Level)
+		if configLogger != nil {
+			loggers = append(loggers, *configLogger)
 		}
-		configLogger := ConfigLogger{Level: level, Formatter: formatter, Granulars: granulars}
-
-		switch filter.Type {
-		case "console":
-			configLogger.LogWriter = new(ConsoleWriter)
-		case "socket":
-			configLogger.LogWriter, err = getJSONSocketWriter(filter)
-			if err != nil {
-				return err
-			}
-		case "file":
-			configLogger.LogWriter, err = getJSONFileWriter(filter)
-			if err != nil {
-				return err
-			}
-		default:
-			log.Printf("TIMBER! Warning unrecognized filter in config file: %v\n", filter.Tag)
-			continue
+	}
+	t.applyConfig(loggers, redactor)
+	return nil
+}
+
+// Builds the logger for an enabled filter, or returns nil if its type is
+// not recognized
+func newJSONConfigLogger(filter JSONFilter) (*ConfigLogger, error) {
+	level, err := parseConfigLevel(filter.Level)
+	if err != nil {
+		return nil, fmt.Errorf("TIMBER! Bad level in filter %q: %v", filter.Tag, err)
+	}
+	maxLevel, err := parseConfigLevel(filter.MaxLevel)
+	if err != nil {
+		return nil, fmt.Errorf("TIMBER! Bad max level in filter %q: %v", filter.Tag, err)
+	}
+	formatter := getJSONFormatter(filter)
+	granulars := make(map[string]Level)
+	for _, granular := range filter.Granulars {
+		if granulars[granular.Path], err = ParseLevel(granular.Level); err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad level for granular %q in filter %q: %v", granular.Path, filter.Tag, err)
+		}
+	}
+	configLogger := ConfigLogger{Tag: filter.Tag, Level: level, MaxLevel: maxLevel, Formatter: formatter, Granulars: granulars}
+	if filter.Match != nil {
+		if configLogger.Filter, err = filter.Match.recordFilter(); err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad match in filter %q: %v", filter.Tag, err)
 		}
+	}
 
-		t.AddLogger(configLogger)
+	switch filter.Type {
+	case "console":
+		configLogger.LogWriter = new(ConsoleWriter)
+	case "socket":
+		configLogger.LogWriter, err = getJSONSocketWriter(filter)
+		if err != nil {
+			return nil, err
+		}
+	case "file":
+		configLogger.LogWriter, err = getJSONFileWriter(filter)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		log.Printf("TIMBER! Warning unrecognized filter in config file: %v\n", filter.Tag)
+		return nil, nil
 	}
-	return nil
+
+	return &configLogger, nil
+}
+
+func (m *JSONMatch) recordFilter() (RecordFilter, error) {
+	extras := make([]configExtra, len(m.Extras))
+	for i, extra := range m.Extras {
+		extras[i] = configExtra{Key: extra.Key, Value: extra.Value}
+	}
+	nested := make([]RecordFilter, len(m.Matches))
+	for i := range m.Matches {
+		var err error
+		if nested[i], err = m.Matches[i].recordFilter(); err != nil {
+			return nil, err
+		}
+	}
+	return newConfigFilter(m.Any, m.Messages, m.NotMessages, extras, m.Sources, m.Hostnames, nested)
 }
 
 func getJSONFormatter(filter JSONFilter) LogFormatter {
@@ -26,14 +26,46 @@ type XMLFilter struct {
 	Tag        string        `xml:"tag"`
 	Type       string        `xml:"type"`
 	Level      string        `xml:"level"`
+	MaxLevel   string        `xml:"maxlevel"`
 	Format     XMLProperty   `xml:"format"`
 	Properties []XMLProperty `xml:"property"`
 	Granulars  []XMLGranular `xml:"granular"`
+	Match      *XMLMatch     `xml:"match"`
+}
+
+// Predicates on the records a filter writes, see RecordFilter.  They must
+// all match, or any of them if Any is set; nested matches combine theirs
+// the same way.  Messages and NotMessages are regular expressions, Sources
+// and Hostnames glob patterns.
+type XMLMatch struct {
+	Any         bool       `xml:"any,attr"`
+	Messages    []string   `xml:"message"`
+	NotMessages []string   `xml:"notmessage"`
+	Extras      []XMLExtra `xml:"extra"`
+	Sources     []string   `xml:"source"`
+	Hostnames   []string   `xml:"hostname"`
+	Matches     []XMLMatch `xml:"match"`
+}
+
+// Matches records with the Extra key, or with that value if it is set
+type XMLExtra struct {
+	Key   string  `xml:"key,attr"`
+	Value *string `xml:"value,attr"`
+}
+
+// Masks secrets in every record, see Redactor.  Rules are the names of
+// RedactRules, patterns are regular expressions
+type XMLRedact struct {
+	Keys     []string `xml:"key"`
+	Rules    []string `xml:"rule"`
+	Patterns []string `xml:"pattern"`
+	Mask     string   `xml:"mask"`
 }
 
 type XMLConfig struct {
 	XMLName xml.Name    `xml:"logging"`
 	Filters []XMLFilter `xml:"filter"`
+	Redact  *XMLRedact  `xml:"redact"`
 }
 
 // Loads the configuration from an XML file (as you were probably expecting)
@@ -54,38 +86,88 @@ func (t *Timber) LoadXMLConfig(filename string) error {
 		return fmt.Errorf("TIMBER! Can't parse xml config file: %s %v", filename, err)
 	}
 
+	var redactor *Redactor
+	if r := config.Redact; r != nil {
+		if redactor, err = newConfigRedactor(r.Keys, r.Rules, r.Patterns, r.Mask); err != nil {
+			return err
+		}
+	}
+
+	var loggers []ConfigLogger
 	for _, filter := range config.Filters {
 		if !filter.Enabled {
 			continue
 		}
-		level := GetLevel(filter.Level)
-		formatter := getXMLFormatter(filter)
-		granulars := make(map[string]Level)
-		for _, granular := range filter.Granulars {
-			granulars[granular.Path] = GetLevel(granular.Level)
+		configLogger, err := newXMLConfigLogger(filter)
+		if err != nil {
+			closeConfigWriters(loggers)
+			return err
 		}
-		configLogger := ConfigLogger{Level: level, Formatter: formatter, Granulars: granulars}
+		if configLogger != nil {
+			loggers = append(loggers, *configLogger)
+		}
+	}
+	t.applyConfig(loggers, redactor)
+	return nil
+}
 
-		var err error
-		switch filter.Type {
-		case "console":
-			configLogger.LogWriter = new(ConsoleWriter)
-		case "socket":
-			if configLogger.LogWriter, err = getXMLSocketWriter(filter); err != nil {
-				return err
-			}
-		case "file":
-			if configLogger.LogWriter, err = getXMLFileWriter(filter); err != nil {
-				return err
-			}
-		default:
-			log.Printf("TIMBER! Warning unrecognized filter in config file: %v\n", filter.Tag)
-			continue
+// Builds the logger for an enabled filter, or returns nil if its type is
+// not recognized
+func newXMLConfigLogger(filter XMLFilter) (*ConfigLogger, error) {
+	level, err := parseConfigLevel(filter.Level)
+	if err != nil {
+		return nil, fmt.Errorf("TIMBER! Bad level in filter %q: %v", filter.Tag, err)
+	}
+	maxLevel, err := parseConfigLevel(filter.MaxLevel)
+	if err != nil {
+		return nil, fmt.Errorf("TIMBER! Bad max level in filter %q: %v", filter.Tag, err)
+	}
+	formatter := getXMLFormatter(filter)
+	granulars := make(map[string]Level)
+	for _, granular := range filter.Granulars {
+		if granulars[granular.Path], err = ParseLevel(granular.Level); err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad level for granular %q in filter %q: %v", granular.Path, filter.Tag, err)
 		}
+	}
+	configLogger := ConfigLogger{Tag: filter.Tag, Level: level, MaxLevel: maxLevel, Formatter: formatter, Granulars: granulars}
+	if filter.Match != nil {
+		if configLogger.Filter, err = filter.Match.recordFilter(); err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad match in filter %q: %v", filter.Tag, err)
+		}
+	}
 
-		t.AddLogger(configLogger)
+	switch filter.Type {
+	case "console":
+		configLogger.LogWriter = new(ConsoleWriter)
+	case "socket":
+		if configLogger.LogWriter, err = getXMLSocketWriter(filter); err != nil {
+			return nil, err
+		}
+	case "file":
+		if configLogger.LogWriter, err = getXMLFileWriter(filter); err != nil {
+			return nil, err
+		}
+	default:
+		log.Printf("TIMBER! Warning unrecognized filter in config file: %v\n", filter.Tag)
+		return nil, nil
 	}
-	return nil
+
+	return &configLogger, nil
+}
+
+func (m *XMLMatch) recordFilter() (RecordFilter, error) {
+	extras := make([]configExtra, len(m.Extras))
+	for i, extra := range m.Extras {
+		extras[i] = configExtra{Key: extra.Key, Value: extra.Value}
+	}
+	nested := make([]RecordFilter, len(m.Matches))
+	for i := range m.Matches {
+		var err error
+		if nested[i], err = m.Matches[i].recordFilter(); err != nil {
+			return nil, err
+		}
+	}
+	return newConfigFilter(m.Any, m.Messages, m.NotMessages, extras, m.Sources, m.Hostnames, nested)
 }
 
 func getXMLFormatter(filter XMLFilter) LogFormatter {
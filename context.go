@@ -0,0 +1,45 @@
+package timber
+
+import (
+	"context"
+	"sync"
+)
+
+// Copies values such as request or trace ids out of a context into the
+// Extra of a record logged with one of the ...Ctx methods
+type ContextExtractor func(ctx context.Context, extra map[string]interface{})
+
+// The ContextExtractors registered with a Timber, shared with its children
+type contextExtractors struct {
+	mutex sync.RWMutex
+	fns   []ContextExtractor
+}
+
+// Register a function to be run on the context of every ...Ctx call.
+// Extractors run in the order they were added so later ones can overwrite
+// keys set by earlier ones.
+func (t *Timber) AddContextExtractor(fn ContextExtractor) {
+	t.extractors.mutex.Lock()
+	defer t.extractors.mutex.Unlock()
+	t.extractors.fns = append(t.extractors.fns, fn)
+}
+
+// Runs the extractors on ctx.  Returns nil if nothing was extracted
+func (t *Timber) contextExtra(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	t.extractors.mutex.RLock()
+	defer t.extractors.mutex.RUnlock()
+	if len(t.extractors.fns) == 0 {
+		return nil
+	}
+	extra := make(map[string]interface{})
+	for _, fn := range t.extractors.fns {
+		fn(ctx, extra)
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	return extra
+}
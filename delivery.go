@@ -0,0 +1,155 @@
+package timber
+
+import (
+	"sync"
+	"time"
+)
+
+// Each ConfigLogger added to a Timber gets its own queue and goroutine for
+// writing records, so a LogWriter that blocks (a socket waiting on a timeout
+// or reconnecting) only delays its own records.  The asyncLumberJack does the
+// level filtering and hands the accepted records over in order.
+//
+// In synchronous mode there is no queue or goroutine and records are written
+// straight away.
+type loggerEntry struct {
+	handle int
+	cfg    ConfigLogger  // only used by asyncLumberJack (or with the sync mutex held)
+	queue  *recordQueue  // nil in synchronous mode
+	done   chan struct{} // closed once the writer has been closed
+	t      *Timber
+	// only used by the delivery goroutine (or with the sync mutex held)
+	collapsed collapser
+}
+
+// Used for a ConfigLogger with a zero Queue.  Dropping rather than blocking
+// keeps a stuck writer from holding up the goroutine that hands records to
+// every logger.
+var DefaultLoggerQueue = QueueConfig{Policy: OverflowDropNewest, MaxRecords: DefaultQueueSize}
+
+func loggerQueueConfig(cfg QueueConfig) QueueConfig {
+	if cfg == (QueueConfig{}) {
+		return DefaultLoggerQueue
+	}
+	return cfg
+}
+
+func (t *Timber) newLoggerEntry(handle int, cfg ConfigLogger) *loggerEntry {
+	e := &loggerEntry{
+		handle: handle,
+		cfg:    cfg,
+		done:   make(chan struct{}),
+		t:      t,
+	}
+	if t.syncLoggers == nil {
+		e.queue = newRecordQueue(loggerQueueConfig(cfg.Queue), t.dropped)
+		go e.deliver(cfg)
+	}
+	return e
+}
+
+func (e *loggerEntry) send(rec *LogRecord) {
+	if e.queue == nil {
+		e.collapsed.write(&e.cfg, rec, e.collapseTimer)
+		return
+	}
+	e.queue.push(rec)
+}
+
+// In synchronous mode the end of a ConfigLogger.Collapse window is handled
+// on a timer goroutine
+func (e *loggerEntry) collapseTimer(d time.Duration) *time.Timer {
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		s := e.t.syncLoggers
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if e.collapsed.timer == timer {
+			e.collapsed.flush(&e.cfg)
+		}
+	})
+	return timer
+}
+
+// Pass a changed cfg on to the delivery goroutine.  Records already queued
+// are written with the old config.
+func (e *loggerEntry) update() {
+	if e.queue == nil {
+		e.collapsed.flush(&e.cfg)
+		return
+	}
+	cfg := e.cfg
+	e.queue.setConfig(loggerQueueConfig(cfg.Queue))
+	e.queue.pushControl(queueItem{cfg: &cfg})
+}
+
+// Flush the writer once the records already queued have been written
+func (e *loggerEntry) flush(wg *sync.WaitGroup) {
+	wg.Add(1)
+	if e.queue == nil {
+		e.collapsed.flush(&e.cfg)
+	}
+	if e.queue == nil || !e.queue.pushControl(queueItem{flushed: wg}) {
+		flushWriter(e.cfg.LogWriter)
+		wg.Done()
+	}
+}
+
+// Write whatever is queued, then close the writer
+func (e *loggerEntry) stop() {
+	if e.queue == nil {
+		e.collapsed.flush(&e.cfg)
+		e.cfg.LogWriter.Close()
+		close(e.done)
+		return
+	}
+	e.queue.close()
+}
+
+func (e *loggerEntry) deliver(cfg ConfigLogger) {
+	defer close(e.done)
+	for {
+		select {
+		case <-e.queue.ready:
+			e.drain(&cfg)
+		case <-e.collapsed.timeout():
+			e.collapsed.flush(&cfg)
+		case <-e.queue.closed:
+			e.drain(&cfg)
+			e.collapsed.flush(&cfg)
+			cfg.LogWriter.Close()
+			return
+		}
+	}
+}
+
+func (e *loggerEntry) drain(cfg *ConfigLogger) {
+	for item, ok := e.queue.pop(); ok; item, ok = e.queue.pop() {
+		if item.cfg != nil {
+			e.collapsed.flush(cfg)
+			*cfg = *item.cfg
+			continue
+		}
+		if item.flushed != nil {
+			e.collapsed.flush(cfg)
+			flushWriter(cfg.LogWriter)
+			item.flushed.Done()
+			continue
+		}
+		e.collapsed.write(cfg, item.rec, time.NewTimer)
+	}
+	if n := e.queue.takeDropped(); n > 0 {
+		writeRecord(cfg, e.t.droppedRecord(n))
+	}
+}
+
+// Flush writers that buffer, such as FileWriter and BufferedWriter
+func flushWriter(w LogWriter) {
+	if f, ok := w.(flusher); ok {
+		f.Flush()
+	}
+}
+
+func writeRecord(cfg *ConfigLogger, rec *LogRecord) {
+	cfg.LogWriter.LogWrite(cfg.Formatter.Format(rec))
+}
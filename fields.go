@@ -0,0 +1,64 @@
+package timber
+
+import "fmt"
+
+// Returns a child logger that adds fields to the LogRecord.Extra of every
+// record it logs.  The child shares the loggers, queue, processors, rate
+// limits and context extractors of its parent (closing or reconfiguring
+// either affects both) and can itself be used to create further children.
+// The exported fields (FileDepth, Hostname, LevelPrefixes and StackLevel)
+// are copied, so setting them on one after With does not change the other.
+// Fields passed to the ...Ex methods take precedence over bound fields with
+// the same key.
+func (t *Timber) With(fields map[string]interface{}) *Timber {
+	child := *t
+	child.fields = mergeExtra(t.fields, fields)
+	child.writeBuf = new(writeBuffer)
+	return &child
+}
+
+// Returns the union of the two maps, with the entries of extra winning.
+// The result is a new map unless one of them is empty; records treat their
+// Extra as read-only so sharing is safe.
+func mergeExtra(fields, extra map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return extra
+	}
+	if len(extra) == 0 {
+		return fields
+	}
+	merged := make(map[string]interface{}, len(fields)+len(extra))
+	for k, v := range fields {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Key used for the value at the end of an odd-length key/value list
+const BadKey = "!BADKEY"
+
+// Builds the extra fields for the ...KV methods from alternating keys and
+// values.  Keys that are not strings are converted with fmt.Sprint and a
+// trailing value without a key is stored under BadKey.  Later duplicates
+// win.
+func kvExtra(kv []interface{}) map[string]interface{} {
+	if len(kv) == 0 {
+		return nil
+	}
+	extra := make(map[string]interface{}, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			extra[BadKey] = kv[i]
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		extra[key] = kv[i+1]
+	}
+	return extra
+}
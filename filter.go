@@ -0,0 +1,173 @@
+package timber
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Decides whether a logger writes a record, on top of its levels, e.g. to
+// send only audit records to an audit file or to keep health check chatter
+// off the console.  Build them with the functions below and combine them
+// with And, Or and Not.  Match must not change the record, nor log through
+// the Timber (see LogWriter).
+type RecordFilter interface {
+	Match(rec *LogRecord) bool
+}
+
+// Adapts an ordinary function to the RecordFilter interface
+type RecordFilterFunc func(rec *LogRecord) bool
+
+func (f RecordFilterFunc) Match(rec *LogRecord) bool {
+	return f(rec)
+}
+
+// Matches records matched by all of the filters (or any record if there
+// are none)
+func And(filters ...RecordFilter) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		for _, f := range filters {
+			if !f.Match(rec) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Matches records matched by any of the filters (or no record if there
+// are none)
+func Or(filters ...RecordFilter) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		for _, f := range filters {
+			if f.Match(rec) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Matches records the filter does not match
+func Not(filter RecordFilter) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		return !filter.Match(rec)
+	})
+}
+
+// Matches records whose message matches re
+func MessageMatches(re *regexp.Regexp) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		return re.MatchString(rec.Message)
+	})
+}
+
+// Matches records whose message does not match re
+func MessageExcludes(re *regexp.Regexp) RecordFilter {
+	return Not(MessageMatches(re))
+}
+
+// Matches records with the key in Extra, whatever its value
+func HasExtra(key string) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		_, ok := rec.Extra[key]
+		return ok
+	})
+}
+
+// Matches records with the value for the key in Extra.  A string value
+// is compared with the value printed by fmt, so that "true" from a config
+// file matches true; other values are compared with reflect.DeepEqual.
+func ExtraEquals(key string, value interface{}) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		v, ok := rec.Extra[key]
+		if !ok {
+			return false
+		}
+		if s, isString := value.(string); isString {
+			return fmt.Sprint(v) == s
+		}
+		return reflect.DeepEqual(v, value)
+	})
+}
+
+// Matches records from source files matching the glob pattern (see
+// path.Match).  A pattern without a slash is matched against the base
+// name of the file, like "*_handler.go", otherwise against the whole path.
+func SourceMatches(pattern string) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		file := rec.SourceFile
+		if !strings.Contains(pattern, "/") {
+			file = path.Base(file)
+		}
+		ok, _ := path.Match(pattern, file)
+		return ok
+	})
+}
+
+// Matches records logged on hosts matching the glob pattern (see
+// path.Match), like "web-*"
+func HostnameMatches(pattern string) RecordFilter {
+	return RecordFilterFunc(func(rec *LogRecord) bool {
+		ok, _ := path.Match(pattern, rec.HostName)
+		return ok
+	})
+}
+
+// An <extra> predicate of a config file: the presence of Key, or its value
+// if Value is set
+type configExtra struct {
+	Key   string
+	Value *string
+}
+
+// Builds the RecordFilter for a <match> section of a config file.  The
+// predicates, including the nested matches, are combined with And, or with
+// Or when or is true.
+func newConfigFilter(or bool, messages, notMessages []string, extras []configExtra, sources, hostnames []string,
+	nested []RecordFilter) (RecordFilter, error) {
+	var filters []RecordFilter
+	for _, pattern := range messages {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad message pattern %q: %v", pattern, err)
+		}
+		filters = append(filters, MessageMatches(re))
+	}
+	for _, pattern := range notMessages {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad message pattern %q: %v", pattern, err)
+		}
+		filters = append(filters, MessageExcludes(re))
+	}
+	for _, extra := range extras {
+		if extra.Key == "" {
+			return nil, fmt.Errorf("TIMBER! Extra predicate without a key")
+		}
+		if extra.Value == nil {
+			filters = append(filters, HasExtra(extra.Key))
+		} else {
+			filters = append(filters, ExtraEquals(extra.Key, *extra.Value))
+		}
+	}
+	for _, pattern := range sources {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad source pattern %q: %v", pattern, err)
+		}
+		filters = append(filters, SourceMatches(pattern))
+	}
+	for _, pattern := range hostnames {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad hostname pattern %q: %v", pattern, err)
+		}
+		filters = append(filters, HostnameMatches(pattern))
+	}
+	filters = append(filters, nested...)
+	if or {
+		return Or(filters...), nil
+	}
+	return And(filters...), nil
+}
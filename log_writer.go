@@ -0,0 +1,164 @@
+package timber
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A message prefix that sets the level of text written to Timber.Write
+type LevelPrefix struct {
+	Prefix string
+	Level  Level
+}
+
+// The prefixes a new Timber looks for in the text passed to Write.  The
+// first match wins; anything without a match is logged at INFO.
+var DefaultLevelPrefixes = []LevelPrefix{
+	{"[FINEST]", FINEST}, {"FINEST:", FINEST},
+	{"[FINE]", FINE}, {"FINE:", FINE},
+	{"[DEBUG]", DEBUG}, {"DEBUG:", DEBUG},
+	{"[TRACE]", TRACE}, {"TRACE:", TRACE},
+	{"[INFO]", INFO}, {"INFO:", INFO},
+	{"[WARN]", WARNING}, {"[WARNING]", WARNING}, {"WARN:", WARNING}, {"WARNING:", WARNING},
+	{"[ERROR]", ERROR}, {"ERROR:", ERROR},
+	{"[CRITICAL]", CRITICAL}, {"CRITICAL:", CRITICAL},
+	{"[FATAL]", CRITICAL}, {"FATAL:", CRITICAL},
+	{"panic:", CRITICAL},
+}
+
+// Packages whose frames are skipped when looking for the caller of Write
+var writeCallerSkip = map[string]bool{
+	"log":      true,
+	"log/slog": true,
+	"fmt":      true,
+	"io":       true,
+	"bufio":    true,
+	"sync":     true, // Close
+}
+
+var timberPackage = reflect.TypeOf(Timber{}).PkgPath()
+
+// Text passed to Write that has not reached a newline yet
+type writeBuffer struct {
+	mutex   sync.Mutex
+	pending []byte
+}
+
+// Adds p to the buffer and returns everything up to the last newline as
+// one message, without the trailing newline.  ok is false if there is no
+// complete message yet.
+func (b *writeBuffer) add(p []byte) (msg string, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	end := bytes.LastIndexByte(p, '\n')
+	if end < 0 {
+		b.pending = append(b.pending, p...)
+		return "", false
+	}
+	msg = string(b.pending) + string(p[:end])
+	b.pending = append(b.pending[:0], p[end+1:]...)
+	return strings.TrimRight(msg, "\r\n"), true
+}
+
+// Empties the buffer, returning what was in it
+func (b *writeBuffer) take() (msg string, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.pending) == 0 {
+		return "", false
+	}
+	msg = string(b.pending)
+	b.pending = nil
+	return msg, true
+}
+
+// This function allows a Timber instance to be used in the standard library
+// log.SetOutput() (call log.SetFlags(0) too, Timber has its own timestamps).
+//
+// Text is buffered until a newline; everything up to the last newline of a
+// Write is logged as one message so multi-line messages stay together, and
+// anything left over is logged by Close if no newline ever comes.  The
+// level is taken from the first of LevelPrefixes the message starts with
+// (ignoring case and leading blanks), otherwise it is INFO.  The message
+// itself is left as is.  The caller is the first frame outside the log, fmt,
+// io and bufio packages and Timber itself, so FileDepth is not used.
+func (t *Timber) Write(p []byte) (n int, err error) {
+	if msg, ok := t.writeBuf.add(p); ok {
+		t.sendWrite(msg)
+	}
+	return len(p), nil
+}
+
+func (t *Timber) sendWrite(msg string) {
+	lvl := t.levelFromPrefix(msg)
+	if !t.Enabled(lvl) {
+		return
+	}
+	select {
+	case <-t.blackHole:
+		return
+	default:
+	}
+	file, line, funcPath := writeCaller()
+	rec := t.newRecord(lvl, makeTimeLogglyCompat(time.Now()), file, line, funcPath, nil, msg)
+	if t.stackWanted(lvl) {
+		rec.Stack = trimStack(captureStack(1), file, line)
+	}
+	t.send(rec)
+}
+
+// Logs any partial line left by Write
+func (t *Timber) flushWriteBuffer() {
+	if msg, ok := t.writeBuf.take(); ok {
+		t.sendWrite(msg)
+	}
+}
+
+func (t *Timber) levelFromPrefix(msg string) Level {
+	msg = strings.TrimLeft(msg, " \t")
+	for _, p := range t.LevelPrefixes {
+		if len(msg) >= len(p.Prefix) && strings.EqualFold(msg[:len(p.Prefix)], p.Prefix) {
+			return p.Level
+		}
+	}
+	return INFO
+}
+
+// Walks up the stack from Write to the code that logged the message
+func writeCaller() (file string, line int, funcPath string) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs) // skip runtime.Callers, writeCaller and sendWrite
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !skipWriteFrame(frame.Function) {
+			return frame.File, frame.Line, frame.Function
+		}
+		if !more {
+			return "", 0, "_"
+		}
+	}
+}
+
+func skipWriteFrame(funcPath string) bool {
+	pkg := funcPackage(funcPath)
+	if pkg == timberPackage {
+		// only Timber's own methods, not other code in the package
+		rest := funcPath[len(pkg):]
+		return strings.HasPrefix(rest, ".(*Timber).") || strings.HasPrefix(rest, ".(*writeBuffer).")
+	}
+	return writeCallerSkip[pkg]
+}
+
+// Returns the package path of a function name like some.package/with/bits.(*Type).Func.func1
+func funcPackage(funcPath string) string {
+	slash := strings.LastIndex(funcPath, "/")
+	if dot := strings.Index(funcPath[slash+1:], "."); dot >= 0 {
+		return funcPath[:slash+1+dot]
+	}
+	return funcPath
+}
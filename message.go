@@ -0,0 +1,55 @@
+package timber
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Builds the message for the log methods from their log4go style arguments.
+// A string is the format for the rest of the arguments, a func() string
+// closure is called (the rest are ignored) and anything else, like an error
+// or fmt.Stringer, is printed along with the rest separated by spaces.
+func formatMessage(arg0 interface{}, args ...interface{}) string {
+	switch first := arg0.(type) {
+	case string:
+		return fmt.Sprintf(first, args...)
+	case func() string:
+		return first()
+	default:
+		msg := fmt.Sprintln(append([]interface{}{arg0}, args...)...)
+		return msg[:len(msg)-1]
+	}
+}
+
+// The error returned by Warn, Error and Critical.  Logging an error on its
+// own returns that error so it can still be unwrapped.
+func newError(msg string, arg0 interface{}, args []interface{}) error {
+	if err, ok := arg0.(error); ok && len(args) == 0 {
+		return err
+	}
+	return errors.New(msg)
+}
+
+// Like newError, but for a disabled level the message is not built
+// unless somebody asks for it
+func deferredError(arg0 interface{}, args []interface{}) error {
+	if err, ok := arg0.(error); ok && len(args) == 0 {
+		return err
+	}
+	return &lazyError{arg0: arg0, args: args}
+}
+
+type lazyError struct {
+	once sync.Once
+	arg0 interface{}
+	args []interface{}
+	msg  string
+}
+
+func (e *lazyError) Error() string {
+	e.once.Do(func() {
+		e.msg = formatMessage(e.arg0, e.args...)
+	})
+	return e.msg
+}
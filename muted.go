@@ -0,0 +1,53 @@
+package timber
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Set on a Timber while some logger has an OFF Level or granular, so that
+// the log methods can skip call sites that every logger has muted before
+// formatting anything.  A new one (with an empty cache) is made whenever
+// the loggers change.
+type mutedPaths struct {
+	loggers []ConfigLogger
+	sites   sync.Map // pc -> bool
+}
+
+// Whether every logger has muted the function
+func (m *mutedPaths) mutes(funcPath string) bool {
+	if funcPath == "" {
+		return false
+	}
+	rec := LogRecord{FuncPath: funcPath}
+	rec.PackagePath, rec.MethodPath = parseFuncName(funcPath)
+	for i := range m.loggers {
+		if loggerLevel(&m.loggers[i], &rec) != OFF {
+			return false
+		}
+	}
+	return true
+}
+
+// Enabled for the caller of the log method calling enabledHere, found with
+// FileDepth like prepare does.  The result for each call site is cached.
+func (t *Timber) enabledHere(lvl Level) bool {
+	if !t.Enabled(lvl) {
+		return false
+	}
+	muted := t.muted.Load()
+	if muted == nil {
+		return true
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(t.FileDepth+1, pcs[:]) == 0 {
+		return true
+	}
+	if off, ok := muted.sites.Load(pcs[0]); ok {
+		return !off.(bool)
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	off := muted.mutes(frame.Function)
+	muted.sites.Store(pcs[0], off)
+	return !off
+}
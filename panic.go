@@ -0,0 +1,115 @@
+package timber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// What Recover does once a panic has been logged
+type PanicAction int
+
+const (
+	// Panic again with the same value (the default)
+	PanicRepanic PanicAction = iota
+	// Exit the program with RecoverOptions.ExitCode
+	PanicExit
+	// Carry on as if the panic never happened
+	PanicSwallow
+)
+
+// How long Recover and the Panic methods wait for the writers to flush if
+// RecoverOptions.FlushTimeout is not set
+const DefaultFlushTimeout = 5 * time.Second
+
+// Options for Recover and Go
+type RecoverOptions struct {
+	Action       PanicAction
+	ExitCode     int           // only used by PanicExit, 1 if zero
+	FlushTimeout time.Duration // DefaultFlushTimeout if zero
+}
+
+// Logs a panic at CRITICAL, with the stack of the panicking goroutine,
+// flushes the writers and then re-panics, exits or returns according to
+// opts.  It must be deferred directly for recover to work:
+//
+//	defer log.Recover(timber.RecoverOptions{Action: timber.PanicSwallow})
+//
+// Does nothing if there is no panic.
+func (t *Timber) Recover(opts RecoverOptions) {
+	if r := recover(); r != nil {
+		t.handlePanic(r, opts)
+	}
+}
+
+// Runs fn on a new goroutine with a deferred Recover, so a panic in it is
+// logged before the program dies.  opts, if given, is passed to Recover.
+func (t *Timber) Go(fn func(), opts ...RecoverOptions) {
+	var o RecoverOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	go func() {
+		defer t.Recover(o)
+		fn()
+	}()
+}
+
+func (t *Timber) handlePanic(r interface{}, opts RecoverOptions) {
+	if t.Enabled(CRITICAL) {
+		select {
+		case <-t.blackHole:
+		default:
+			t.send(t.panicRecord(r))
+		}
+	}
+	t.flushTimeout(opts.FlushTimeout)
+
+	switch opts.Action {
+	case PanicExit:
+		code := opts.ExitCode
+		if code == 0 {
+			code = 1
+		}
+		os.Exit(code)
+	case PanicSwallow:
+	default:
+		panic(r)
+	}
+}
+
+// Builds the record for a recovered panic.  The deferred functions run on
+// top of the panicking stack, so the caller is the first frame below the
+// runtime's panic handling.
+func (t *Timber) panicRecord(r interface{}) *LogRecord {
+	stack := captureStack(2)
+	for i, f := range stack {
+		if f.Function == "runtime.gopanic" {
+			i++
+			for i < len(stack) && strings.HasPrefix(stack[i].Function, "runtime.") {
+				i++
+			}
+			stack = stack[i:]
+			break
+		}
+	}
+	file, line, funcPath := "", 0, "_"
+	if len(stack) > 0 {
+		file, line, funcPath = stack[0].File, stack[0].Line, stack[0].Function
+	}
+	rec := t.newRecord(CRITICAL, makeTimeLogglyCompat(time.Now()), file, line, funcPath, nil, fmt.Sprintf("panic: %v", r))
+	rec.Stack = stack
+	return rec
+}
+
+// Flushes the writers, giving up after timeout (DefaultFlushTimeout if zero)
+func (t *Timber) flushTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultFlushTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	t.Flush(ctx)
+}
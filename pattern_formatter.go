@@ -29,6 +29,7 @@ type PatFormatter struct {
 //   %% - Percent sign
 // 	 %P - Caller Path: package path + calling function name
 // 	 %p - Caller Path: package path
+// 	 %B - Stack: LogRecord.Stack one frame per line, empty if there is none
 // the string number prefixes are allowed e.g.: %10s will pad the source field to 10 spaces
 func NewPatFormatter(format string) *PatFormatter {
 	pf := new(PatFormatter)
@@ -172,6 +173,14 @@ func (pf *PatFormatter) compileForLevel(level int) []byte {
 			sprintfFmt = append(sprintfFmt, 's')
 			sprintfFmt = append(sprintfFmt, fmt_str[1:]...)
 			pf.formatDynamic = append(pf.formatDynamic, 'p')
+		case 'B':
+			sprintfFmt = append(sprintfFmt, '%')
+			if num != nil {
+				sprintfFmt = append(sprintfFmt, num...)
+			}
+			sprintfFmt = append(sprintfFmt, 's')
+			sprintfFmt = append(sprintfFmt, fmt_str[1:]...)
+			pf.formatDynamic = append(pf.formatDynamic, 'B')
 		default:
 			sprintfFmt = append(sprintfFmt, fmt_str...)
 		} // end switch
@@ -203,9 +212,9 @@ func (pf *PatFormatter) getDynamic(rec *LogRecord) []interface{} {
 		case 'D', 'd':
 			ret = append(ret, parseDate(tm)...)
 		case 'L':
-			ret = append(ret, LevelStrings[rec.Level])
+			ret = append(ret, ShortLevelName(rec.Level))
 		case 'l':
-			ret = append(ret, LongLevelStrings[rec.Level])
+			ret = append(ret, LevelName(rec.Level))
 		case 'S':
 			ret = append(ret, parseSourceLong(rec.SourceFile, rec.SourceLine))
 		case 's':
@@ -218,6 +227,8 @@ func (pf *PatFormatter) getDynamic(rec *LogRecord) []interface{} {
 			ret = append(ret, rec.FuncPath)
 		case 'p':
 			ret = append(ret, rec.PackagePath)
+		case 'B':
+			ret = append(ret, formatStack(rec.Stack))
 		}
 	}
 	return ret
@@ -0,0 +1,89 @@
+package timber
+
+import (
+	"sync"
+)
+
+// Enriches, changes or drops records before they are formatted, e.g. to
+// add a build version or to throw away health check noise.  Process gets
+// a copy of the record that it may change freely (Extra is a copy of the
+// map too, never nil, but the values in it are shared) and returns false
+// to drop it.
+//
+// Processors added to a Timber run once for each record before the level
+// filtering, those in ConfigLogger.Processors run for each logger that
+// accepts the record on a copy of its own.  They are all called from one
+// goroutine at a time.  Like a LogWriter, a processor must not log through
+// its own Timber, which can deadlock.
+type RecordProcessor interface {
+	Process(rec *LogRecord) bool
+}
+
+// Adapts an ordinary function to the RecordProcessor interface
+type RecordProcessorFunc func(rec *LogRecord) bool
+
+func (f RecordProcessorFunc) Process(rec *LogRecord) bool {
+	return f(rec)
+}
+
+// The RecordProcessors added to a Timber, shared with its children
+type recordProcessors struct {
+	mutex sync.RWMutex
+	list  []RecordProcessor
+	// the Redactor from the last config file with a <redact> section, also
+	// in list
+	configRedactor *Redactor
+}
+
+// Register a processor to be run on every record logged.  Processors run
+// in the order they were added.
+func (t *Timber) AddProcessor(p RecordProcessor) {
+	t.processors.mutex.Lock()
+	defer t.processors.mutex.Unlock()
+	t.processors.list = append(t.processors.list, p)
+}
+
+// Installs the Redactor of a config file in place of the one from a config
+// file loaded before, so loading a file again does not stack them
+func (t *Timber) setConfigRedactor(r *Redactor) {
+	t.processors.mutex.Lock()
+	defer t.processors.mutex.Unlock()
+	if old := t.processors.configRedactor; old != nil {
+		for i, p := range t.processors.list {
+			if p == RecordProcessor(old) {
+				t.processors.list[i] = r
+				t.processors.configRedactor = r
+				return
+			}
+		}
+	}
+	t.processors.list = append(t.processors.list, r)
+	t.processors.configRedactor = r
+}
+
+// Runs the processors of the Timber on rec, see runProcessors
+func (t *Timber) process(rec *LogRecord) *LogRecord {
+	t.processors.mutex.RLock()
+	defer t.processors.mutex.RUnlock()
+	return runProcessors(t.processors.list, rec)
+}
+
+// Runs processors on a copy of rec and returns it, or nil if one of them
+// dropped it.  rec itself is never changed; it is returned as is if there
+// are no processors.
+func runProcessors(processors []RecordProcessor, rec *LogRecord) *LogRecord {
+	if len(processors) == 0 {
+		return rec
+	}
+	c := *rec
+	c.Extra = copyExtra(rec.Extra)
+	for _, p := range processors {
+		if !p.Process(&c) {
+			return nil
+		}
+	}
+	if len(c.Extra) == 0 {
+		c.Extra = nil
+	}
+	return &c
+}
@@ -0,0 +1,140 @@
+package timber
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits how often one call site can log: Records per Interval on average,
+// with up to Burst records at once (Records if zero).  Records over the
+// limit are thrown away and counted; the next record let through from the
+// same call site is preceded by a "suppressed N similar messages" record.
+type RateLimit struct {
+	Records  int
+	Interval time.Duration
+	Burst    int
+}
+
+func (l RateLimit) isZero() bool {
+	return l.Records <= 0 || l.Interval <= 0
+}
+
+func (l RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Records)
+}
+
+// The rate limits of a Timber and the state of each call site
+type rateLimits struct {
+	enabled atomic.Bool // fast path when no limits are set
+	mutex   sync.Mutex
+	all     RateLimit            // for call sites not in paths
+	paths   map[string]RateLimit // by function, method or package path
+	sites   map[uintptr]*siteLimit
+}
+
+// Token bucket for one call site
+type siteLimit struct {
+	limit      RateLimit // zero if unlimited
+	tokens     float64
+	last       time.Time
+	suppressed uint64
+}
+
+func (r *rateLimits) set(path string, limit RateLimit) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if path == "" {
+		r.all = limit
+	} else if limit.isZero() {
+		delete(r.paths, path)
+	} else {
+		if r.paths == nil {
+			r.paths = make(map[string]RateLimit)
+		}
+		r.paths[path] = limit
+	}
+	// call sites look their limit up again
+	r.sites = nil
+	r.enabled.Store(!r.all.isZero() || len(r.paths) > 0)
+}
+
+// only call with the mutex held
+func (r *rateLimits) lookup(funcPath string) RateLimit {
+	if len(r.paths) > 0 && funcPath != "_" {
+		packagePath, methodPath := parseFuncName(funcPath)
+		for _, path := range []string{funcPath, methodPath, packagePath} {
+			if limit, ok := r.paths[path]; ok {
+				return limit
+			}
+		}
+	}
+	return r.all
+}
+
+// Takes a token for the call site at pc.  Returns false if the record is
+// over the limit, otherwise the number of records suppressed since the last
+// one let through.
+func (r *rateLimits) allow(pc uintptr, funcPath string, now time.Time) (ok bool, suppressed uint64) {
+	if !r.enabled.Load() {
+		return true, 0
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	site := r.sites[pc]
+	if site == nil {
+		limit := r.lookup(funcPath)
+		site = &siteLimit{limit: limit, tokens: limit.burst(), last: now}
+		if r.sites == nil {
+			r.sites = make(map[uintptr]*siteLimit)
+		}
+		r.sites[pc] = site
+	}
+	if site.limit.isZero() {
+		return true, 0
+	}
+
+	elapsed := now.Sub(site.last)
+	site.last = now
+	site.tokens += elapsed.Seconds() * float64(site.limit.Records) / site.limit.Interval.Seconds()
+	if burst := site.limit.burst(); site.tokens > burst {
+		site.tokens = burst
+	}
+	if site.tokens < 1 {
+		site.suppressed++
+		return false, 0
+	}
+	site.tokens--
+	suppressed = site.suppressed
+	site.suppressed = 0
+	return true, suppressed
+}
+
+// Sets the rate limit for every call site without a limit of its own.  A
+// zero RateLimit removes it.
+func (t *Timber) SetRateLimit(limit RateLimit) {
+	t.limits.set("", limit)
+}
+
+// Sets the rate limit for the call sites in a function, method or package
+// path, matched like the Granulars of a ConfigLogger.  A zero RateLimit
+// removes it.
+func (t *Timber) SetRateLimitFor(path string, limit RateLimit) {
+	if path != "" {
+		t.limits.set(path, limit)
+	}
+}
+
+// The record telling that n records from the same call site as rec have
+// been suppressed
+func (t *Timber) suppressedRecord(rec *LogRecord, n uint64) *LogRecord {
+	summary := *rec
+	summary.Message = fmt.Sprintf("TIMBER! suppressed %d similar messages", n)
+	summary.Extra = t.fields
+	summary.Stack = nil
+	return &summary
+}
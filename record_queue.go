@@ -0,0 +1,276 @@
+package timber
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// What happens to a new record when a record queue is full
+type OverflowPolicy int
+
+const (
+	// Wait until there is room in the queue (the default for the Timber queue)
+	OverflowBlock OverflowPolicy = iota
+	// Throw away the record being logged
+	OverflowDropNewest
+	// Throw away the oldest queued records to make room
+	OverflowDropOldest
+	// Wait up to QueueConfig.Timeout for room, then throw away the record being logged
+	OverflowBlockTimeout
+)
+
+// Number of records a queue holds if neither MaxRecords nor MaxBytes is set
+const DefaultQueueSize = 300
+
+// Size limits and overflow behaviour of a record queue.  The queue is full
+// when either limit is reached; a zero limit is ignored.  When both are zero
+// the queue holds DefaultQueueSize records.  A single record is always accepted
+// by an empty queue, even if it is bigger than MaxBytes.
+type QueueConfig struct {
+	Policy     OverflowPolicy
+	MaxRecords int
+	MaxBytes   int           // approximate; see recordSize
+	Timeout    time.Duration // only used by OverflowBlockTimeout
+}
+
+// fixed cost of a record on top of its strings, used for MaxBytes
+const recordOverhead = 128
+
+// Records are queued along with control messages for the goroutine
+// reading the queue.  Control messages ignore the size limits and are
+// never dropped.
+type queueItem struct {
+	rec     *LogRecord
+	cfg     *ConfigLogger   // replaces the config of a delivery goroutine
+	flushed *sync.WaitGroup // flush the writer then call Done
+}
+
+func (item queueItem) size() int {
+	if item.rec == nil {
+		return 0
+	}
+	return recordSize(item.rec)
+}
+
+// A bounded FIFO of records between the goroutines calling the log methods
+// and the goroutine writing them.  The ready and space channels are
+// signalled (never blocking) on push and pop so both sides can select on
+// them alongside other channels.
+type recordQueue struct {
+	mutex        sync.Mutex
+	cfg          QueueConfig
+	items        []queueItem
+	bytes        int
+	records      int            // number of items that are records
+	dropped      uint64         // dropped since the last call to takeDropped
+	totalDropped *atomic.Uint64 // shared by all the queues of a Timber
+	ready        chan struct{}
+	space        chan struct{}
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+func newRecordQueue(cfg QueueConfig, totalDropped *atomic.Uint64) *recordQueue {
+	return &recordQueue{
+		cfg:          cfg,
+		totalDropped: totalDropped,
+		ready:        make(chan struct{}, 1),
+		space:        make(chan struct{}, 1),
+		closed:       make(chan struct{}),
+	}
+}
+
+// approximate memory used by a queued record
+func recordSize(rec *LogRecord) int {
+	size := recordOverhead + len(rec.Message) + len(rec.SourceFile) + len(rec.FuncPath) +
+		len(rec.MethodPath) + len(rec.PackagePath) + len(rec.HostName)
+	for _, f := range rec.Stack {
+		size += len(f.Function) + len(f.File) + 16
+	}
+	return size
+}
+
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+func (q *recordQueue) setConfig(cfg QueueConfig) {
+	q.mutex.Lock()
+	q.cfg = cfg
+	q.mutex.Unlock()
+	// limits may have grown so let any blocked callers re-check
+	signal(q.space)
+}
+
+// only call with the mutex held
+func (q *recordQueue) hasRoom(size int) bool {
+	if q.records == 0 {
+		return true
+	}
+	maxRecords := q.cfg.MaxRecords
+	if maxRecords <= 0 && q.cfg.MaxBytes <= 0 {
+		maxRecords = DefaultQueueSize
+	}
+	if maxRecords > 0 && q.records >= maxRecords {
+		return false
+	}
+	if q.cfg.MaxBytes > 0 && q.bytes+size > q.cfg.MaxBytes {
+		return false
+	}
+	return true
+}
+
+// only call with the mutex held
+func (q *recordQueue) append(item queueItem, size int) {
+	q.items = append(q.items, item)
+	q.bytes += size
+	if item.rec != nil {
+		q.records++
+	}
+	room := q.hasRoom(0)
+	signal(q.ready)
+	if room {
+		// pass the wake up on to the next blocked caller
+		signal(q.space)
+	}
+}
+
+// only call with the mutex held
+func (q *recordQueue) remove(i int) queueItem {
+	item := q.items[i]
+	if i == 0 {
+		q.items[0] = queueItem{}
+		q.items = q.items[1:]
+	} else {
+		copy(q.items[i:], q.items[i+1:])
+		q.items[len(q.items)-1] = queueItem{}
+		q.items = q.items[:len(q.items)-1]
+	}
+	q.bytes -= item.size()
+	if item.rec != nil {
+		q.records--
+	}
+	if len(q.items) == 0 {
+		q.items = nil
+	}
+	return item
+}
+
+// only call with the mutex held
+func (q *recordQueue) removeOldestRecord() {
+	for i, item := range q.items {
+		if item.rec != nil {
+			q.remove(i)
+			return
+		}
+	}
+}
+
+// only call with the mutex held
+func (q *recordQueue) drop(n int) {
+	q.dropped += uint64(n)
+	q.totalDropped.Add(uint64(n))
+}
+
+// Add a record to the queue, applying the overflow policy if it is full.
+// Records pushed after close are discarded.
+func (q *recordQueue) push(rec *LogRecord) {
+	item := queueItem{rec: rec}
+	size := item.size()
+	var timeout <-chan time.Time
+	for {
+		q.mutex.Lock()
+		select {
+		case <-q.closed:
+			q.mutex.Unlock()
+			return
+		default:
+		}
+		if q.hasRoom(size) {
+			q.append(item, size)
+			q.mutex.Unlock()
+			return
+		}
+		switch q.cfg.Policy {
+		case OverflowDropNewest:
+			q.drop(1)
+			q.mutex.Unlock()
+			return
+		case OverflowDropOldest:
+			for !q.hasRoom(size) {
+				q.removeOldestRecord()
+				q.drop(1)
+			}
+			q.append(item, size)
+			q.mutex.Unlock()
+			return
+		case OverflowBlockTimeout:
+			if timeout == nil {
+				timer := time.NewTimer(q.cfg.Timeout)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+		}
+		q.mutex.Unlock()
+
+		select {
+		case <-q.space:
+		case <-q.closed:
+			return
+		case <-timeout:
+			q.mutex.Lock()
+			q.drop(1)
+			q.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// Add a control message to the queue.  Returns false (and discards the
+// message) after close.
+func (q *recordQueue) pushControl(item queueItem) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	select {
+	case <-q.closed:
+		return false
+	default:
+		q.append(item, 0)
+		return true
+	}
+}
+
+// Remove the oldest item.  ok is false if the queue is empty
+func (q *recordQueue) pop() (item queueItem, ok bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.items) == 0 {
+		return queueItem{}, false
+	}
+	item = q.remove(0)
+	signal(q.space)
+	return item, true
+}
+
+// Returns the number of records dropped since the last call and resets it
+func (q *recordQueue) takeDropped() uint64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	n := q.dropped
+	q.dropped = 0
+	return n
+}
+
+// Stop accepting records and release any blocked callers.  Records already
+// queued can still be popped.
+func (q *recordQueue) close() {
+	q.closeOnce.Do(func() {
+		q.mutex.Lock()
+		close(q.closed)
+		q.mutex.Unlock()
+	})
+}
@@ -0,0 +1,105 @@
+package timber
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// What redacted text is replaced with if Redactor.Mask is empty
+const DefaultRedactMask = "[REDACTED]"
+
+// Ready made patterns for a Redactor
+var (
+	RedactCardNumbers  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	RedactBearerTokens = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	RedactEmails       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// The ready made patterns by the name used for them in <redact><rule>
+var RedactRules = map[string]*regexp.Regexp{
+	"cards":  RedactCardNumbers,
+	"bearer": RedactBearerTokens,
+	"emails": RedactEmails,
+}
+
+// A RecordProcessor that masks secrets and personal data.  The values of
+// Extra fields with one of the keys (ignoring case, also inside nested
+// maps) are replaced with the Mask, and text matching the patterns is
+// replaced in the Message and in string values of Extra.
+type Redactor struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+	Mask     string
+}
+
+func NewRedactor(keys []string, patterns ...*regexp.Regexp) *Redactor {
+	r := &Redactor{keys: make(map[string]bool, len(keys)), patterns: patterns}
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = true
+	}
+	return r
+}
+
+func (r *Redactor) Process(rec *LogRecord) bool {
+	rec.Message = r.redactString(rec.Message)
+	for k, v := range rec.Extra {
+		rec.Extra[k] = r.redactField(k, v)
+	}
+	return true
+}
+
+func (r *Redactor) mask() string {
+	if r.Mask == "" {
+		return DefaultRedactMask
+	}
+	return r.Mask
+}
+
+func (r *Redactor) redactString(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllLiteralString(s, r.mask())
+	}
+	return s
+}
+
+// Nested maps are shared with other copies of the record so they are
+// copied rather than changed
+func (r *Redactor) redactField(key string, value interface{}) interface{} {
+	if r.keys[strings.ToLower(key)] {
+		return r.mask()
+	}
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, nested := range v {
+			m[k] = r.redactField(k, nested)
+		}
+		return m
+	}
+	return value
+}
+
+// Builds the Redactor for a <redact> section of a config file
+func newConfigRedactor(keys, rules, patterns []string, mask string) (*Redactor, error) {
+	var regexps []*regexp.Regexp
+	for _, rule := range rules {
+		re, ok := RedactRules[rule]
+		if !ok {
+			return nil, fmt.Errorf("TIMBER! Unknown redact rule %q", rule)
+		}
+		regexps = append(regexps, re)
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("TIMBER! Bad redact pattern %q: %v", pattern, err)
+		}
+		regexps = append(regexps, re)
+	}
+	r := NewRedactor(keys, regexps...)
+	r.Mask = mask
+	return r, nil
+}
@@ -0,0 +1,169 @@
+package timber
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// An slog.Handler that logs through a Timber
+type slogHandler struct {
+	t      *Timber
+	extra  map[string]interface{} // attributes from WithAttrs
+	groups []string               // groups from WithGroup
+}
+
+// Returns an slog.Handler that sends records to the loggers of t, so code
+// using log/slog ends up in the same place as everything else:
+//
+//	slog.SetDefault(slog.New(timber.NewSlogHandler(timber.Global)))
+//
+// Levels are mapped with SlogLevel.  Attributes are added to LogRecord.Extra
+// with groups as nested maps, alongside any fields bound with With and any
+// values read from the context by the ContextExtractors.  The source file,
+// function and package come from the PC of the slog record so granulars
+// work as for direct callers.
+func NewSlogHandler(t *Timber) slog.Handler {
+	return &slogHandler{t: t}
+}
+
+// Returns the timber Level for an slog.Level.  The standard slog levels map
+// to DEBUG, INFO, WARNING and ERROR; each step of 4 below LevelDebug goes to
+// FINE then FINEST and anything from LevelError+4 up is CRITICAL.
+func SlogLevel(lvl slog.Level) Level {
+	switch {
+	case lvl < slog.LevelDebug-4:
+		return FINEST
+	case lvl < slog.LevelDebug:
+		return FINE
+	case lvl < slog.LevelInfo:
+		return DEBUG
+	case lvl < slog.LevelWarn:
+		return INFO
+	case lvl < slog.LevelError:
+		return WARNING
+	case lvl < slog.LevelError+4:
+		return ERROR
+	default:
+		return CRITICAL
+	}
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.t.Enabled(SlogLevel(lvl))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	select {
+	case <-h.t.blackHole:
+		return nil
+	default:
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	extra := mergeExtra(h.t.contextExtra(ctx), addGroupAttrs(h.extra, h.groups, attrs))
+
+	file := ""
+	line := 0
+	funcPath := "_"
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file, line = frame.File, frame.Line
+		if frame.Function != "" {
+			funcPath = frame.Function
+		}
+	}
+	// a zero time means the record has none, leave it that way
+	now := r.Time
+	if !now.IsZero() {
+		now = makeTimeLogglyCompat(now)
+	}
+	rec := h.t.newRecord(SlogLevel(r.Level), now, file, line, funcPath, extra, r.Message)
+	if takeStackExtra(rec) || h.t.stackWanted(rec.Level) {
+		rec.Stack = trimStack(captureStack(1), file, line)
+	}
+	h.t.send(rec)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &slogHandler{t: h.t, extra: addGroupAttrs(h.extra, h.groups, attrs), groups: h.groups}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := make([]string, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+	return &slogHandler{t: h.t, extra: h.extra, groups: append(groups, name)}
+}
+
+// Returns a copy of extra with attrs added in the nested map for groups.
+// extra itself is never changed, as it is shared by the handlers and
+// records built from it, and it is returned as is if there is nothing to
+// add so empty groups never appear.
+func addGroupAttrs(extra map[string]interface{}, groups []string, attrs []slog.Attr) map[string]interface{} {
+	added := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		addAttr(added, a)
+	}
+	if len(added) == 0 {
+		return extra
+	}
+
+	result := copyExtra(extra)
+	m := result
+	for _, g := range groups {
+		sub, _ := m[g].(map[string]interface{})
+		sub = copyExtra(sub)
+		m[g] = sub
+		m = sub
+	}
+	for k, v := range added {
+		m[k] = v
+	}
+	return result
+}
+
+func copyExtra(extra map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(extra)+1)
+	for k, v := range extra {
+		c[k] = v
+	}
+	return c
+}
+
+// Adds an attribute to m following the slog.Handler rules: values are
+// resolved, empty attributes and groups are ignored and groups without a
+// key are inlined
+func addAttr(m map[string]interface{}, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		m[a.Key] = a.Value.Any()
+		return
+	}
+	if a.Key == "" {
+		for _, ga := range a.Value.Group() {
+			addAttr(m, ga)
+		}
+		return
+	}
+	sub := make(map[string]interface{})
+	for _, ga := range a.Value.Group() {
+		addAttr(sub, ga)
+	}
+	if len(sub) > 0 {
+		m[a.Key] = sub
+	}
+}
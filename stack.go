@@ -0,0 +1,92 @@
+package timber
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// One frame of the goroutine stack in LogRecord.Stack, innermost first
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Setting this key to true in the extra fields passed to an ...Ex method
+// captures the stack for that record whatever the Timber.StackLevel.  The
+// key itself is removed from the record.
+const StackExtra = "timber.stack"
+
+// Most frames kept in LogRecord.Stack
+const maxStackFrames = 64
+
+// Returns the stack of the calling goroutine, skipping skip frames as for
+// runtime.Callers
+func captureStack(skip int) []StackFrame {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]StackFrame, 0, n)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "runtime.goexit" {
+			stack = append(stack, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+// Drops the frames above the caller at file:line, such as those of slog or
+// the log package.  The stack is returned as is if the caller is not in it.
+func trimStack(stack []StackFrame, file string, line int) []StackFrame {
+	for i, f := range stack {
+		if f.File == file && f.Line == line {
+			return stack[i:]
+		}
+	}
+	return stack
+}
+
+// Whether a record at lvl gets a stack without being asked for one
+func (t *Timber) stackWanted(lvl Level) bool {
+	return t.StackLevel != NONE && lvl >= t.StackLevel
+}
+
+// Removes StackExtra from the extra fields of rec, returning whether it
+// asked for a stack
+func takeStackExtra(rec *LogRecord) bool {
+	v, ok := rec.Extra[StackExtra]
+	if !ok {
+		return false
+	}
+	extra := make(map[string]interface{}, len(rec.Extra)-1)
+	for k, val := range rec.Extra {
+		if k != StackExtra {
+			extra[k] = val
+		}
+	}
+	if len(extra) == 0 {
+		extra = nil
+	}
+	rec.Extra = extra
+	forced, _ := v.(bool)
+	return forced
+}
+
+// The stack as text, one "function\n\tfile:line" pair per frame like a
+// panic, each line starting with a newline and indented by a tab
+func formatStack(stack []StackFrame) string {
+	var b strings.Builder
+	for _, f := range stack {
+		b.WriteString("\n\t")
+		b.WriteString(f.Function)
+		b.WriteString("\n\t\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package timber
+
+import (
+	"sync"
+)
+
+// Creates a new Timber logger that formats and writes each record on the
+// goroutine calling the log method, so nothing is lost or reordered if the
+// program exits without calling Close.  Useful for short-lived command line
+// tools and tests.  It supports the same Logger and MultiLogger methods as
+// the asynchronous Timber; the queue settings are ignored.
+//
+// Records are written with a mutex held, so a LogWriter, RecordProcessor or
+// RecordFilter that logs through the same Timber deadlocks.
+func NewSyncTimber() *Timber {
+	t := newTimber()
+	t.syncLoggers = new(syncLoggers)
+	return t
+}
+
+// The loggers of a synchronous Timber.  The mutex is held while records
+// are written so writers never see concurrent calls.
+type syncLoggers struct {
+	mutex   sync.Mutex
+	loggers []*loggerEntry
+	closed  bool
+}
+
+func (s *syncLoggers) send(t *Timber, rec *LogRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.closed {
+		t.sendToLoggers(s.loggers, rec)
+	}
+}
+
+func (s *syncLoggers) reconfigure(t *Timber, tc timberConfig) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	s.loggers = t.reconfigure(s.loggers, tc)
+	return true
+}
+
+func (s *syncLoggers) close(blackHole chan int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	close(blackHole)
+	stopAllLoggers(s.loggers)
+}
@@ -13,7 +13,9 @@ import (
 // Mapping from the timber levels to the syslog severity
 // If you override this, make sure all the entries are in the map
 // since the syslog.Priority zero value will cause a message at 
-// the Emergency severity
+// the Emergency severity.  Levels added with RegisterLevel can be
+// added here too, otherwise they get the severity of CRITICAL (or
+// NONE if they are negative)
 var DefaultSeverityMap = map[Level]syslog.Priority{
 	NONE:     syslog.LOG_INFO,
 	FINEST:   syslog.LOG_DEBUG,
@@ -49,10 +51,22 @@ func NewSyslogFormatter(format string) *SyslogFormatter {
 func (sf *SyslogFormatter) Format(rec *LogRecord) string {
 	msg := sf.pf.Format(rec)
 	return fmt.Sprintf("<%d>%.15s %s[%d]: %s",
-		sf.Facility|sf.SeverityMap[rec.Level],
+		sf.Facility|sf.severity(rec.Level),
 		rec.Timestamp.Format(time.Stamp),
 		sf.Tag,
 		sf.pid,
 		msg)
 }
 
+func (sf *SyslogFormatter) severity(lvl Level) syslog.Priority {
+	if severity, ok := sf.SeverityMap[lvl]; ok {
+		return severity
+	}
+	switch {
+	case lvl > CRITICAL:
+		lvl = CRITICAL
+	case lvl < NONE:
+		lvl = NONE
+	}
+	return sf.SeverityMap[lvl]
+}
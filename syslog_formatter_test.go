@@ -0,0 +1,26 @@
+// Same build constraints as log/syslog
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package timber
+
+import (
+	"log/syslog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogCustomLevel(t *testing.T) {
+	a := assert.New(t)
+
+	sf := NewSyslogFormatter("%L %M")
+	msg := sf.Format(&LogRecord{Level: AUDIT, Timestamp: time.Now(), Message: "audited"})
+	a.True(strings.HasPrefix(msg, "<10>"))
+	a.True(strings.HasSuffix(msg, ": AUDT audited\n"))
+
+	sf.SeverityMap = map[Level]syslog.Priority{AUDIT: syslog.LOG_NOTICE}
+	a.Equal(syslog.LOG_NOTICE, sf.severity(AUDIT))
+}
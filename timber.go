@@ -70,7 +70,8 @@
 //	  </filter>
 //	</logging>
 //
-// The <tag> is ignored.
+// The <tag> is kept in ConfigLogger.Tag so the logger can be found at runtime with
+// LoggerByTag, e.g. to change its level, format or granulars.
 //
 // To configure the pattern formatter all filters accept:
 //
@@ -90,6 +91,7 @@
 //	%% - Percent sign
 //	%P - Caller Path: packagePath.CallingFunctionName
 //	%p - Caller Path: packagePath
+//	%B - Stack: the frames of LogRecord.Stack, one per line (see Timber.StackLevel)
 //
 // the string number prefixes are allowed e.g.: %10s will pad the source field to 10 spaces
 // pattern defaults to %M
@@ -99,6 +101,7 @@
 //   - Create one or many <granular> within a filter
 //   - Define a <level> and <path> within, where path can be path to package or path to
 //     package.FunctionName. Function name definitions override package paths.
+//   - A granular <level> of OFF mutes the path completely
 //
 // Code Architecture:
 // A MultiLogger <logging> which consists of many ConfigLoggers <filter>. ConfigLoggers have three properties:
@@ -119,13 +122,16 @@
 package timber
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -144,6 +150,11 @@ const (
 	CRITICAL
 )
 
+// Threshold that lets nothing through, to mute a logger (as its Level) or
+// a noisy package or function (in its Granulars).  Nothing can be logged
+// at OFF itself.
+const OFF Level = math.MaxInt32
+
 // Default level passed to runtime.Caller by Timber, add to this if you wrap Timber in your own logging code
 const DefaultFileDepth int = 3
 
@@ -163,16 +174,144 @@ var LongLevelStrings = []string{
 	"CRITICAL",
 }
 
-// GetLevel returns a given level string as the actual Level value
+// ParseLevel returns the Level for a full level name (FINEST...CRITICAL or
+// one added with RegisterLevel), a short name as printed by %L (FNST, WARN,
+// EROR...) or a number.  Names are not case sensitive.  Unlike GetLevel it
+// returns an error for anything else.
+func ParseLevel(lvlString string) (Level, error) {
+	str := strings.TrimSpace(lvlString)
+	if strings.EqualFold(str, offName) {
+		return OFF, nil
+	}
+	if str != "" {
+		for idx := range LongLevelStrings {
+			if strings.EqualFold(str, LongLevelStrings[idx]) || strings.EqualFold(str, LevelStrings[idx]) {
+				return Level(idx), nil
+			}
+		}
+		customLevels.RLock()
+		defer customLevels.RUnlock()
+		for lvl, names := range customLevels.names {
+			if strings.EqualFold(str, names.long) || strings.EqualFold(str, names.short) {
+				return lvl, nil
+			}
+		}
+		if n, err := strconv.Atoi(str); err == nil {
+			return Level(n), nil
+		}
+	}
+	return NONE, fmt.Errorf("TIMBER! Unknown level %q", lvlString)
+}
+
+// GetLevel returns a given level string as the actual Level value.  Unknown
+// strings give NONE, which logs everything; ParseLevel reports them instead.
 func GetLevel(lvlString string) Level {
 	for idx, str := range LongLevelStrings {
 		if str == lvlString {
 			return Level(idx)
 		}
 	}
+	if lvlString == offName {
+		return OFF
+	}
+	customLevels.RLock()
+	defer customLevels.RUnlock()
+	for lvl, names := range customLevels.names {
+		if names.long == lvlString {
+			return lvl
+		}
+	}
 	return Level(0)
 }
 
+const offName = "OFF"
+
+// Levels added with RegisterLevel
+var customLevels struct {
+	sync.RWMutex
+	names map[Level]levelNames
+}
+
+type levelNames struct {
+	long  string
+	short string
+}
+
+// Adds a level such as NOTICE or AUDIT.  name is used by GetLevel, the
+// config files and %l, short by %L.  Levels are ordered by value like the
+// built in ones, so a level above CRITICAL is logged by every logger with
+// a lower threshold; value must not be one of the built in levels.  Meant
+// to be called from init, it panics if the value or either name is taken.
+func RegisterLevel(name, short string, value Level) {
+	if value >= NONE && value <= CRITICAL {
+		panic(fmt.Sprintf("TIMBER! Level %d is the built in level %s", value, LongLevelStrings[value]))
+	}
+	if value >= OFF {
+		panic(fmt.Sprintf("TIMBER! Level %d is not below OFF", value))
+	}
+	customLevels.Lock()
+	defer customLevels.Unlock()
+	if name == "" || levelNameTaken(name) {
+		panic(fmt.Sprintf("TIMBER! Level name %q is already taken", name))
+	}
+	if short != "" && levelNameTaken(short) {
+		panic(fmt.Sprintf("TIMBER! Short level name %q is already taken", short))
+	}
+	if names, ok := customLevels.names[value]; ok {
+		panic(fmt.Sprintf("TIMBER! Level %d is already registered as %s", value, names.long))
+	}
+	if customLevels.names == nil {
+		customLevels.names = make(map[Level]levelNames)
+	}
+	customLevels.names[value] = levelNames{long: name, short: short}
+}
+
+// ParseLevel accepts long and short names in any case, so a new name must
+// not match any of them.  Only call with customLevels locked.
+func levelNameTaken(str string) bool {
+	if strings.EqualFold(str, offName) {
+		return true
+	}
+	for idx := range LongLevelStrings {
+		if strings.EqualFold(str, LongLevelStrings[idx]) || strings.EqualFold(str, LevelStrings[idx]) {
+			return true
+		}
+	}
+	for _, names := range customLevels.names {
+		if strings.EqualFold(str, names.long) || strings.EqualFold(str, names.short) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the full name of a level, built in or registered, or its number
+// if it has no name
+func LevelName(lvl Level) string {
+	return lookupLevelNames(lvl).long
+}
+
+// Returns the name of a level as printed by %L
+func ShortLevelName(lvl Level) string {
+	return lookupLevelNames(lvl).short
+}
+
+func lookupLevelNames(lvl Level) levelNames {
+	if lvl >= NONE && lvl <= CRITICAL {
+		return levelNames{long: LongLevelStrings[lvl], short: LevelStrings[lvl]}
+	}
+	if lvl == OFF {
+		return levelNames{long: offName, short: offName}
+	}
+	customLevels.RLock()
+	defer customLevels.RUnlock()
+	if names, ok := customLevels.names[lvl]; ok {
+		return names
+	}
+	number := strconv.Itoa(int(lvl))
+	return levelNames{long: number, short: number}
+}
+
 // This explicitly defines the contract for a logger
 // Not really useful except for documentation for
 // writing an separate implementation
@@ -220,6 +359,28 @@ type Logger interface {
 	ErrorEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) error
 	CriticalEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) error
 	LogEx(extra map[string]interface{}, lvl Level, arg0 interface{}, args ...interface{})
+
+	// read extra fields out of a context with the registered ContextExtractors
+	FinestCtx(ctx context.Context, arg0 interface{}, args ...interface{})
+	FineCtx(ctx context.Context, arg0 interface{}, args ...interface{})
+	DebugCtx(ctx context.Context, arg0 interface{}, args ...interface{})
+	TraceCtx(ctx context.Context, arg0 interface{}, args ...interface{})
+	InfoCtx(ctx context.Context, arg0 interface{}, args ...interface{})
+	WarnCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error
+	ErrorCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error
+	CriticalCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error
+	LogCtx(ctx context.Context, lvl Level, arg0 interface{}, args ...interface{})
+
+	// extra fields as alternating keys and values, ie InfoKV("done", "user", id)
+	FinestKV(msg string, kv ...interface{})
+	FineKV(msg string, kv ...interface{})
+	DebugKV(msg string, kv ...interface{})
+	TraceKV(msg string, kv ...interface{})
+	InfoKV(msg string, kv ...interface{})
+	WarnKV(msg string, kv ...interface{}) error
+	ErrorKV(msg string, kv ...interface{}) error
+	CriticalKV(msg string, kv ...interface{}) error
+	LogKV(lvl Level, msg string, kv ...interface{})
 }
 
 // Not used
@@ -234,6 +395,10 @@ type LoggerConfig interface {
 // io.WriteCloser with no errors allowed to be returned and string
 // instead of []byte.
 //
+// A LogWriter must not log through the Timber it belongs to: a synchronous
+// Timber holds its mutex while writing and would deadlock, and an
+// asynchronous one can block on its own full queue.
+//
 // TODO: Maybe this should just be a standard io.WriteCloser?
 type LogWriter interface {
 	LogWrite(msg string)
@@ -253,6 +418,8 @@ type LogRecord struct {
 	PackagePath string
 	HostName    string
 	Extra       map[string]interface{} `json:"extra,omitempty"`
+	// Only set at or above Timber.StackLevel, or when asked for with StackExtra
+	Stack []StackFrame `json:"stack,omitempty"`
 }
 
 // Format a log message before writing
@@ -262,21 +429,51 @@ type LogFormatter interface {
 
 // Container a single log format/destination
 type ConfigLogger struct {
+	// Optional name used to find the logger with LoggerByTag.  The config
+	// loaders set it from the filter <tag>
+	Tag       string
 	LogWriter LogWriter
 	// Messages with level < Level will be ignored.  It's up to the implementor to keep the contract or not
 	Level     Level
 	Formatter LogFormatter
 	Granulars map[string]Level
+	// Messages with level > MaxLevel will be ignored too, whatever Level
+	// and the Granulars say.  NONE (the default) has no maximum
+	MaxLevel Level
+	// Records are written to LogWriter on a goroutine of its own through
+	// a queue with these limits, so a slow writer does not hold up the others.
+	// A zero Queue drops new records once DefaultQueueSize are waiting (see
+	// DefaultLoggerQueue).  A blocking policy holds up every logger and the
+	// callers while this queue is full.
+	Queue QueueConfig
+	// Identical records (same level, call site, message and Extra) repeating the
+	// last one written within this long are held back and reported by one
+	// record with RepeatedExtra set.  Zero writes every record.
+	Collapse time.Duration
+	// Only records it matches are written, after the level checks.  nil
+	// matches every record
+	Filter RecordFilter
+	// Run on the records this logger accepts, after those of the Timber
+	Processors []RecordProcessor
 }
 
 // Allow logging to multiple places
 type MultiLogger interface {
-	// returns an int that identifies the logger for future calls to SetLevel and SetFormatter
+	// returns a handle that identifies the logger for future calls to SetLogger,
+	// SetLevel, SetFormatter and RemoveLogger.  Handles are not positions and
+	// stay valid when other loggers are removed
 	AddLogger(logger ConfigLogger) int
-	SetLogger(index int, logger ConfigLogger)
+	SetLogger(handle int, logger ConfigLogger) error
+	// closes the writer once it has written everything queued for it
+	RemoveLogger(handle int) error
 	// dynamically change level or format
-	SetLevel(index int, lvl Level)
-	SetFormatter(index int, formatter LogFormatter)
+	SetLevel(handle int, lvl Level) error
+	SetFormatter(handle int, formatter LogFormatter) error
+	SetGranulars(handle int, granulars map[string]Level) error
+	// returns the handle of the first logger with the given ConfigLogger.Tag
+	LoggerByTag(tag string) (int, error)
+	// wait for queued records to be written and flushed
+	Flush(ctx context.Context) error
 	Close()
 }
 
@@ -292,20 +489,34 @@ type MultiLogger interface {
 // New instances may be created, but usually you'll just want to use the default
 // instance in Global
 //
-// NOTE: I don't supporting the log4go special handling of the first parameter based on type
-// mainly cuz I don't think it's particularly useful (I kept passing a data string as the first
-// param and expecting a Println-like output but that would always break expecting a format string)
-// I also don't support the passing of the closure stuff
+// The first parameter of the log methods is handled like log4go: a string is a format
+// string for the rest, a func() string closure is only called if the level is enabled,
+// and anything else (an error, a fmt.Stringer...) is printed along with the rest like Println
 type Timber struct {
 	writerConfigChan chan timberConfig
-	recordChan       chan *LogRecord
+	queue            *recordQueue
+	dropped          *atomic.Uint64
+	syncLoggers      *syncLoggers // only set in synchronous mode
+	lastHandle       *atomic.Int64
+	minLevel         *atomic.Int64 // lowest level any logger accepts
+	muted            *atomic.Pointer[mutedPaths]
+	fields           map[string]interface{} // bound by With
+	extractors       *contextExtractors
+	processors       *recordProcessors
+	limits           *rateLimits
 	hasLogger        bool
 	closeLatch       *sync.Once
 	blackHole        chan int
+	writeBuf         *writeBuffer // partial line passed to Write
 	// This value is passed to runtime.Caller to get the file name/line and may require
 	// tweaking if you want to wrap the logger
 	FileDepth int
 	Hostname  func() string
+	// Prefixes that set the level of messages passed to Write
+	LevelPrefixes []LevelPrefix
+	// Records logged at or above this level get the goroutine stack in
+	// LogRecord.Stack.  NONE (the default) captures no stacks.
+	StackLevel Level
 }
 
 type timberAction int
@@ -313,133 +524,300 @@ type timberAction int
 const (
 	actionAdd timberAction = iota
 	actionSet
+	actionRemove
 	actionModify
+	actionFind
+	actionFlush
 	actionQuit
 )
 
 type timberConfig struct {
-	Action timberAction // type of config action
-	Index  int          // only for modify
-	Cfg    ConfigLogger // used for modify or add
-	Ret    chan int     // only used for add
+	Action  timberAction        // type of config action
+	Handle  int                 // only for set, remove and modify
+	Tag     string              // only for find
+	Cfg     ConfigLogger        // used for set or add
+	Modify  func(*ConfigLogger) // only for modify
+	Flushed *sync.WaitGroup     // only for flush
+	Ret     chan int            // only used for add and find
+	Err     chan error          // used for everything except add
 }
 
 // Creates a new Timber logger that is ready to be configured
 // With no subsequent configuration, nothing will be logged
 func NewTimber() *Timber {
+	t := newTimber()
+	go t.asyncLumberJack()
+	return t
+}
+
+// The setup shared by the asynchronous and synchronous Timber
+func newTimber() *Timber {
 	t := new(Timber)
 	t.writerConfigChan = make(chan timberConfig)
-	t.recordChan = make(chan *LogRecord, 300)
+	t.dropped = new(atomic.Uint64)
+	t.lastHandle = new(atomic.Int64)
+	t.minLevel = new(atomic.Int64)
+	t.muted = new(atomic.Pointer[mutedPaths])
+	t.minLevel.Store(math.MaxInt64) // no loggers
+	t.extractors = new(contextExtractors)
+	t.processors = new(recordProcessors)
+	t.limits = new(rateLimits)
+	t.queue = newRecordQueue(QueueConfig{}, t.dropped)
 	t.FileDepth = DefaultFileDepth
+	t.LevelPrefixes = DefaultLevelPrefixes
+	t.writeBuf = new(writeBuffer)
 	t.closeLatch = &sync.Once{}
 	t.blackHole = make(chan int)
 	t.Hostname = func() string {
 		h, _ := os.Hostname()
 		return h
 	}
-	go t.asyncLumberJack()
 	return t
 }
 
 func (t *Timber) asyncLumberJack() {
-	var loggers []ConfigLogger = make([]ConfigLogger, 0, 2)
+	var loggers []*loggerEntry = make([]*loggerEntry, 0, 2)
 	loopIt := true
 	for loopIt {
 		select {
-		case rec := <-t.recordChan:
-			sendToLoggers(loggers, rec)
+		case <-t.queue.ready:
+			t.drainQueue(loggers)
 		case cfg := <-t.writerConfigChan:
+			// records logged before the config change use the old config
+			t.drainQueue(loggers)
 			switch cfg.Action {
-			case actionAdd:
-				loggers = append(loggers, cfg.Cfg)
-				cfg.Ret <- (len(loggers) - 1)
-			case actionSet:
-				// Old writer may want to flush, close handles etc.
-				loggers[cfg.Index].LogWriter.Close()
-				loggers[cfg.Index] = cfg.Cfg
-			case actionModify:
 			case actionQuit:
 				close(t.blackHole)
+				t.queue.close()
 				loopIt = false
 				defer func() {
 					cfg.Ret <- 0
 				}()
+			default:
+				loggers = t.reconfigure(loggers, cfg)
 			}
 		} // select
 	} // for
-	// drain the log channel before closing (best effort)
-	loopIt = true
-	for loopIt {
-		select {
-		case rec := <-t.recordChan:
-			sendToLoggers(loggers, rec)
-		default:
-			loopIt = false
+	// drain the log queue before closing (best effort)
+	t.drainQueue(loggers)
+	stopAllLoggers(loggers)
+}
+
+// Apply an add, set, remove or modify action to the loggers.  Runs on the
+// asyncLumberJack, or with the mutex held in synchronous mode.  The caller
+// is only answered once the lowest level and muted paths have been updated,
+// so a record logged straight after AddLogger or SetLevel is not dropped.
+func (t *Timber) reconfigure(loggers []*loggerEntry, cfg timberConfig) []*loggerEntry {
+	var reply func()
+	switch cfg.Action {
+	case actionAdd:
+		e := t.newLoggerEntry(int(t.lastHandle.Add(1)-1), cfg.Cfg)
+		loggers = append(loggers, e)
+		reply = func() { cfg.Ret <- e.handle }
+	case actionSet:
+		idx, err := findLogger(loggers, cfg.Handle)
+		if err != nil {
+			cfg.Err <- err
+			break
+		}
+		// Old writer may want to flush, close handles etc.
+		old := loggers[idx]
+		old.stop()
+		loggers[idx] = t.newLoggerEntry(old.handle, cfg.Cfg)
+		reply = func() { go waitForClose(old, cfg.Err) }
+	case actionRemove:
+		idx, err := findLogger(loggers, cfg.Handle)
+		if err != nil {
+			cfg.Err <- err
+			break
+		}
+		old := loggers[idx]
+		old.stop()
+		loggers = append(loggers[:idx:idx], loggers[idx+1:]...)
+		reply = func() { go waitForClose(old, cfg.Err) }
+	case actionModify:
+		idx, err := findLogger(loggers, cfg.Handle)
+		if err != nil {
+			cfg.Err <- err
+			break
+		}
+		cfg.Modify(&loggers[idx].cfg)
+		loggers[idx].update()
+		reply = func() { cfg.Err <- nil }
+	case actionFind:
+		for _, e := range loggers {
+			if e.cfg.Tag == cfg.Tag {
+				cfg.Ret <- e.handle
+				cfg.Err <- nil
+				return loggers
+			}
+		}
+		cfg.Err <- fmt.Errorf("TIMBER! No logger with tag %q", cfg.Tag)
+	case actionFlush:
+		for _, e := range loggers {
+			e.flush(cfg.Flushed)
 		}
+		cfg.Err <- nil
 	}
-	closeAllWriters(loggers)
+	t.updateMinLevel(loggers)
+	if reply != nil {
+		reply()
+	}
+	return loggers
 }
 
-func sendToLogger(rec *LogRecord, granLevel Level, formatted string, cLog ConfigLogger) bool {
-	if rec.Level >= granLevel || granLevel == 0 {
-		if formatted == "" {
-			formatted = cLog.Formatter.Format(rec)
+// Work out the lowest level that any logger or granular accepts so the log
+// methods can return straight away for anything below it.
+func (t *Timber) updateMinLevel(loggers []*loggerEntry) {
+	var minLevel int64 = math.MaxInt64
+	lower := func(lvl Level) {
+		if lvl == NONE {
+			// no threshold; everything is logged
+			minLevel = math.MinInt64
+		} else if int64(lvl) < minLevel {
+			minLevel = int64(lvl)
 		}
-		cLog.LogWriter.LogWrite(formatted)
-		return true
 	}
-	return false
+	var muted *mutedPaths
+	for _, e := range loggers {
+		lower(e.cfg.Level)
+		off := e.cfg.Level == OFF
+		for _, lvl := range e.cfg.Granulars {
+			lower(lvl)
+			off = off || lvl == OFF
+		}
+		if off && muted == nil {
+			muted = new(mutedPaths)
+		}
+	}
+	if muted != nil {
+		for _, e := range loggers {
+			muted.loggers = append(muted.loggers, e.cfg)
+		}
+	}
+	t.minLevel.Store(minLevel)
+	t.muted.Store(muted)
 }
 
-func sendToLoggers(loggers []ConfigLogger, rec *LogRecord) {
-	formatted := ""
-	for _, cLog := range loggers {
-		// Find any function level definitions.
-		gLevel, ok := cLog.Granulars[rec.FuncPath]
-		if ok {
-			sendToLogger(rec, gLevel, formatted, cLog)
-			continue
-		}
-		// Find any package + method level definitions.
-		gLevel, ok = cLog.Granulars[rec.MethodPath]
-		if ok {
-			sendToLogger(rec, gLevel, formatted, cLog)
-			continue
+// Reports whether a record at lvl could be written by any of the loggers.
+// The log methods check this before doing any work, and it can be used to
+// skip building expensive arguments that would only be thrown away.
+func (t *Timber) Enabled(lvl Level) bool {
+	return lvl < OFF && int64(lvl) >= t.minLevel.Load()
+}
+
+// Send everything in the queue to the loggers.  Once the queue has caught
+// up, report any records the overflow policy threw away.
+func (t *Timber) drainQueue(loggers []*loggerEntry) {
+	for item, ok := t.queue.pop(); ok; item, ok = t.queue.pop() {
+		t.sendToLoggers(loggers, item.rec)
+	}
+	if n := t.queue.takeDropped(); n > 0 {
+		t.sendToLoggers(loggers, t.droppedRecord(n))
+	}
+}
+
+func (t *Timber) droppedRecord(n uint64) *LogRecord {
+	return t.prepare(WARNING, nil, fmt.Sprintf("TIMBER! %d records dropped", n), 2)
+}
+
+// Returns the position of the logger with the given handle
+func findLogger(loggers []*loggerEntry, handle int) (int, error) {
+	for idx, e := range loggers {
+		if e.handle == handle {
+			return idx, nil
 		}
-		// Find any package level definitions.
-		gLevel, ok = cLog.Granulars[rec.PackagePath]
-		if ok {
-			sendToLogger(rec, gLevel, formatted, cLog)
-			continue
+	}
+	return -1, fmt.Errorf("TIMBER! Unknown logger handle %d", handle)
+}
+
+// Report back once the old writer has finished and closed
+func waitForClose(old *loggerEntry, errChan chan error) {
+	<-old.done
+	errChan <- nil
+}
+
+// Checks the record level against the logger threshold
+func acceptsLevel(rec *LogRecord, granLevel Level) bool {
+	return granLevel != OFF && (rec.Level >= granLevel || granLevel == 0)
+}
+
+func belowMaxLevel(cLog *ConfigLogger, rec *LogRecord) bool {
+	return cLog.MaxLevel == NONE || rec.Level <= cLog.MaxLevel
+}
+
+// Finds the threshold for the record, using any granular definitions first
+func loggerLevel(cLog *ConfigLogger, rec *LogRecord) Level {
+	// Find any function level definitions.
+	if gLevel, ok := cLog.Granulars[rec.FuncPath]; ok {
+		return gLevel
+	}
+	// Find any package + method level definitions.
+	if gLevel, ok := cLog.Granulars[rec.MethodPath]; ok {
+		return gLevel
+	}
+	// Find any package level definitions.
+	if gLevel, ok := cLog.Granulars[rec.PackagePath]; ok {
+		return gLevel
+	}
+	// Use default definition
+	return cLog.Level
+}
+
+func (t *Timber) sendToLoggers(loggers []*loggerEntry, rec *LogRecord) {
+	if rec = t.process(rec); rec == nil {
+		return
+	}
+	for _, e := range loggers {
+		if acceptsLevel(rec, loggerLevel(&e.cfg, rec)) && belowMaxLevel(&e.cfg, rec) &&
+			(e.cfg.Filter == nil || e.cfg.Filter.Match(rec)) {
+			if r := runProcessors(e.cfg.Processors, rec); r != nil {
+				e.send(r)
+			}
 		}
-		// Use default definition
-		sendToLogger(rec, cLog.Level, formatted, cLog)
 	}
 }
 
-func closeAllWriters(cls []ConfigLogger) {
-	for _, cLog := range cls {
-		cLog.LogWriter.Close()
+// Stop all the delivery goroutines and wait for the writers to close
+func stopAllLoggers(loggers []*loggerEntry) {
+	for _, e := range loggers {
+		e.stop()
+	}
+	for _, e := range loggers {
+		<-e.done
 	}
 }
 
-// MultiLogger interface
+// MultiLogger interface, returns -1 if the Timber has been closed
 func (t *Timber) AddLogger(logger ConfigLogger) int {
 	tcChan := make(chan int, 1) // buffered
 	tc := timberConfig{Action: actionAdd, Cfg: logger, Ret: tcChan}
-	t.writerConfigChan <- tc
+	if !t.sendConfig(tc) {
+		return -1
+	}
 	return <-tcChan
 }
 
-func (t *Timber) SetLogger(index int, logger ConfigLogger) {
-	tcChan := make(chan int, 1) // buffered
-	tc := timberConfig{Action: actionSet, Cfg: logger, Ret: tcChan, Index: index}
-	t.writerConfigChan <- tc
+// MultiLogger interface
+func (t *Timber) SetLogger(handle int, logger ConfigLogger) error {
+	tc := timberConfig{Action: actionSet, Cfg: logger, Handle: handle}
+	return t.configure(tc)
+}
+
+// MultiLogger interface
+func (t *Timber) RemoveLogger(handle int) error {
+	tc := timberConfig{Action: actionRemove, Handle: handle}
+	return t.configure(tc)
 }
 
 // MultiLogger interface
 func (t *Timber) Close() {
 	t.closeLatch.Do(func() {
+		t.flushWriteBuffer()
+		if s := t.syncLoggers; s != nil {
+			s.close(t.blackHole)
+			return
+		}
 		tcChan := make(chan int)
 		tc := timberConfig{Action: actionQuit, Ret: tcChan}
 		t.writerConfigChan <- tc
@@ -447,14 +825,126 @@ func (t *Timber) Close() {
 	})
 }
 
-// Not yet implemented
-func (t *Timber) SetLevel(index int, lvl Level) {
-	// TODO
+// MultiLogger interface
+func (t *Timber) SetLevel(handle int, lvl Level) error {
+	tc := timberConfig{Action: actionModify, Handle: handle, Modify: func(cLog *ConfigLogger) {
+		cLog.Level = lvl
+	}}
+	return t.configure(tc)
+}
+
+// MultiLogger interface
+func (t *Timber) SetFormatter(handle int, formatter LogFormatter) error {
+	tc := timberConfig{Action: actionModify, Handle: handle, Modify: func(cLog *ConfigLogger) {
+		cLog.Formatter = formatter
+	}}
+	return t.configure(tc)
+}
+
+// MultiLogger interface
+func (t *Timber) SetGranulars(handle int, granulars map[string]Level) error {
+	// copy so the caller is free to change the map afterwards
+	gran := make(map[string]Level, len(granulars))
+	for path, lvl := range granulars {
+		gran[path] = lvl
+	}
+	tc := timberConfig{Action: actionModify, Handle: handle, Modify: func(cLog *ConfigLogger) {
+		cLog.Granulars = gran
+	}}
+	return t.configure(tc)
+}
+
+// MultiLogger interface
+func (t *Timber) LoggerByTag(tag string) (int, error) {
+	tc := timberConfig{Action: actionFind, Tag: tag, Ret: make(chan int, 1)}
+	if err := t.configure(tc); err != nil {
+		return -1, err
+	}
+	return <-tc.Ret, nil
+}
+
+// Change the size and overflow policy of the queue between the log methods
+// and the goroutine that writes the records.  The default is to block the
+// caller once DefaultQueueSize records are waiting.
+func (t *Timber) SetQueueConfig(cfg QueueConfig) {
+	t.queue.setConfig(cfg)
+}
+
+// Total number of records thrown away by the overflow policies of the
+// Timber queue and the ConfigLogger queues
+func (t *Timber) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// MultiLogger interface
+// Blocks until everything logged so far has been written and all the
+// writers that buffer (FileWriter, BufferedWriter) have been flushed, or
+// until ctx is done.  Unlike Close, logging carries on as normal afterwards.
+func (t *Timber) Flush(ctx context.Context) error {
+	wg := new(sync.WaitGroup)
+	tc := timberConfig{Action: actionFlush, Flushed: wg, Err: make(chan error, 1)}
+	if s := t.syncLoggers; s != nil {
+		// the writes have happened already, but flushing may still block
+		go func() {
+			if !s.reconfigure(t, tc) {
+				tc.Err <- errClosed
+			}
+		}()
+	} else {
+		select {
+		case t.writerConfigChan <- tc:
+		case <-t.blackHole:
+			return errClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	select {
+	case err := <-tc.Err:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// Not yet implemented
-func (t *Timber) SetFormatter(index int, formatter LogFormatter) {
-	// TODO
+var errClosed = errors.New("TIMBER! Logger is closed")
+
+// Send a set or modify action and wait for the result.
+func (t *Timber) configure(tc timberConfig) error {
+	tc.Err = make(chan error, 1) // buffered
+	if !t.sendConfig(tc) {
+		return errClosed
+	}
+	return <-tc.Err
+}
+
+// Hand a config action to the asyncLumberJack, or apply it directly in
+// synchronous mode.  Returns false if the Timber has been closed rather than
+// blocking forever.
+func (t *Timber) sendConfig(tc timberConfig) bool {
+	if s := t.syncLoggers; s != nil {
+		return s.reconfigure(t, tc)
+	}
+	select {
+	case t.writerConfigChan <- tc:
+		return true
+	case <-t.blackHole:
+		return false
+	}
 }
 
 // Logger interface
@@ -474,7 +964,23 @@ func (t *Timber) doPrepareAndSend(lvl Level, extra map[string]interface{}, msg s
 		// then it always succeeds so we avoid writing
 		// to the closed channel
 	default:
-		t.recordChan <- t.prepare(lvl, extra, msg, depth+2) // +2 required to accommodate the prepareAndSend function(s) in the call stack
+		rec, suppressed := t.prepareLimited(lvl, extra, msg, depth+2) // +2 required to accommodate the prepareAndSend function(s) in the call stack
+		if rec == nil {
+			return
+		}
+		if suppressed > 0 {
+			t.send(t.suppressedRecord(rec, suppressed))
+		}
+		t.send(rec)
+	}
+}
+
+// Hand a prepared record to the loggers
+func (t *Timber) send(rec *LogRecord) {
+	if s := t.syncLoggers; s != nil {
+		s.send(t, rec)
+	} else {
+		t.queue.push(rec)
 	}
 }
 
@@ -507,13 +1013,50 @@ func makeTimeLogglyCompat(t time.Time) time.Time {
 
 func (t *Timber) prepare(lvl Level, extra map[string]interface{}, msg string, depth int) *LogRecord {
 	now := makeTimeLogglyCompat(time.Now())
-	pc, file, line, _ := runtime.Caller(depth)
-	funcPath := "_"
-	packagePath := "_"
-	methodPath := "_"
+	_, file, line, funcPath := callerAt(depth)
+	return t.addStack(t.newRecord(lvl, now, file, line, funcPath, extra, msg), depth)
+}
+
+// Like prepare but applies the rate limits of the call site.  Returns nil
+// if the record is over the limit, otherwise the number of earlier records
+// from the call site that were not.
+func (t *Timber) prepareLimited(lvl Level, extra map[string]interface{}, msg string, depth int) (*LogRecord, uint64) {
+	now := makeTimeLogglyCompat(time.Now())
+	pc, file, line, funcPath := callerAt(depth)
+	ok, suppressed := t.limits.allow(pc, funcPath, now)
+	if !ok {
+		return nil, 0
+	}
+	return t.addStack(t.newRecord(lvl, now, file, line, funcPath, extra, msg), depth), suppressed
+}
+
+// depth is passed to runtime.Caller by the function calling callerAt.
+// funcPath is "_" if unknown.
+func callerAt(depth int) (pc uintptr, file string, line int, funcPath string) {
+	pc, file, line, _ = runtime.Caller(depth + 1)
+	funcPath = "_"
 	me := runtime.FuncForPC(pc)
 	if me != nil {
 		funcPath = me.Name()
+	}
+	return pc, file, line, funcPath
+}
+
+// Captures the stack for rec if it is wanted; depth is as for callerAt
+func (t *Timber) addStack(rec *LogRecord, depth int) *LogRecord {
+	if takeStackExtra(rec) || t.stackWanted(rec.Level) {
+		rec.Stack = captureStack(depth + 2)
+	}
+	return rec
+}
+
+// Builds a record for a caller that has already been located.  funcPath is
+// "_" if unknown.
+func (t *Timber) newRecord(lvl Level, now time.Time, file string, line int, funcPath string,
+	extra map[string]interface{}, msg string) *LogRecord {
+	packagePath := "_"
+	methodPath := "_"
+	if funcPath != "_" {
 		packagePath, methodPath = parseFuncName(funcPath)
 	}
 
@@ -531,168 +1074,348 @@ func (t *Timber) prepare(lvl Level, extra map[string]interface{}, msg string, de
 		MethodPath:  methodPath,
 		PackagePath: packagePath,
 		HostName:    hostname,
-		Extra:       extra,
+		Extra:       mergeExtra(t.fields, extra),
 	}
 }
 
-// This function allows a Timber instance to be used in the standard library
-// log.SetOutput().  It is not a general Writer interface and assumes one
-// message per call to Write. All messages are send at level INFO
-func (t *Timber) Write(p []byte) (n int, err error) {
-	t.prepareAndSend(INFO, string(bytes.TrimSpace(p)), 4)
-	return len(p), nil
-}
-
 func (t *Timber) Finest(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(FINEST, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(FINEST) {
+		t.prepareAndSend(FINEST, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Fine(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(FINE, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(FINE) {
+		t.prepareAndSend(FINE, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Debug(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(DEBUG, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSend(DEBUG, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Trace(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(TRACE, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(TRACE) {
+		t.prepareAndSend(TRACE, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Info(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(INFO, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(INFO) {
+		t.prepareAndSend(INFO, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Warn(arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(WARNING) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSend(WARNING, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) Error(arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(ERROR) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSend(ERROR, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) Critical(arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(CRITICAL) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) Log(lvl Level, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(lvl, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(lvl) {
+		t.prepareAndSend(lvl, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 
 // The govet printf family of warnings triggers on Erorr() and similar containing format strings
 // Add more golike Foof() formatters. Other methods should be considered deprecated
 func (t *Timber) Finestf(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(FINEST, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(FINEST) {
+		t.prepareAndSend(FINEST, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Finef(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(FINE, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(FINE) {
+		t.prepareAndSend(FINE, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Debugf(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(DEBUG, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSend(DEBUG, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Tracef(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(TRACE, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(TRACE) {
+		t.prepareAndSend(TRACE, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Infof(arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(INFO, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(INFO) {
+		t.prepareAndSend(INFO, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) Warnf(arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(WARNING) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSend(WARNING, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) Errorf(arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(ERROR) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSend(ERROR, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) Criticalf(arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(CRITICAL) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) Logf(lvl Level, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSend(lvl, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(lvl) {
+		t.prepareAndSend(lvl, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 
 // Print won't work well with a pattern_logger because it explicitly adds
 // its own \n; so you'd have to write your own formatter to remove it
 func (t *Timber) Print(v ...interface{}) {
-	t.prepareAndSend(DEBUG, fmt.Sprint(v...), t.FileDepth)
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSend(DEBUG, fmt.Sprint(v...), t.FileDepth)
+	}
 }
 func (t *Timber) Printf(format string, v ...interface{}) {
-	t.prepareAndSend(DEBUG, fmt.Sprintf(format, v...), t.FileDepth)
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSend(DEBUG, fmt.Sprintf(format, v...), t.FileDepth)
+	}
 }
 
 // Println won't work well either with a pattern_logger because it explicitly adds
 // its own \n; so you'd have to write your own formatter to not have 2 \n's
 func (t *Timber) Println(v ...interface{}) {
-	t.prepareAndSend(DEBUG, fmt.Sprintln(v...), t.FileDepth)
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSend(DEBUG, fmt.Sprintln(v...), t.FileDepth)
+	}
 }
 func (t *Timber) Panic(v ...interface{}) {
 	msg := fmt.Sprint(v...)
-	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	if t.enabledHere(CRITICAL) {
+		t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	}
+	t.flushTimeout(0)
 	panic(msg)
 }
 func (t *Timber) Panicf(format string, v ...interface{}) {
 	msg := fmt.Sprintf(format, v...)
-	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	if t.enabledHere(CRITICAL) {
+		t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	}
+	t.flushTimeout(0)
 	panic(msg)
 }
 func (t *Timber) Panicln(v ...interface{}) {
 	msg := fmt.Sprintln(v...)
-	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	if t.enabledHere(CRITICAL) {
+		t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	}
+	t.flushTimeout(0)
 	panic(msg)
 }
 func (t *Timber) Fatal(v ...interface{}) {
 	msg := fmt.Sprint(v...)
-	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	if t.enabledHere(CRITICAL) {
+		t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	}
 	t.Close()
 	os.Exit(1)
 }
 func (t *Timber) Fatalf(format string, v ...interface{}) {
 	msg := fmt.Sprintf(format, v...)
-	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	if t.enabledHere(CRITICAL) {
+		t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	}
 	t.Close()
 	os.Exit(1)
 }
 func (t *Timber) Fatalln(v ...interface{}) {
 	msg := fmt.Sprintln(v...)
-	t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	if t.enabledHere(CRITICAL) {
+		t.prepareAndSend(CRITICAL, msg, t.FileDepth)
+	}
 	t.Close()
 	os.Exit(1)
 }
 
 func (t *Timber) FinestEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSendEx(FINEST, extra, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(FINEST) {
+		t.prepareAndSendEx(FINEST, extra, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) FineEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSendEx(FINE, extra, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(FINE) {
+		t.prepareAndSendEx(FINE, extra, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) DebugEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSendEx(DEBUG, extra, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSendEx(DEBUG, extra, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) TraceEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSendEx(TRACE, extra, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(TRACE) {
+		t.prepareAndSendEx(TRACE, extra, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) InfoEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSendEx(INFO, extra, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(INFO) {
+		t.prepareAndSendEx(INFO, extra, formatMessage(arg0, args...), t.FileDepth)
+	}
 }
 func (t *Timber) WarnEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(WARNING) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSendEx(WARNING, extra, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) ErrorEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(ERROR) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSendEx(ERROR, extra, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) CriticalEx(extra map[string]interface{}, arg0 interface{}, args ...interface{}) error {
-	msg := fmt.Sprintf(arg0.(string), args...)
+	if !t.enabledHere(CRITICAL) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
 	t.prepareAndSendEx(CRITICAL, extra, msg, t.FileDepth)
-	return errors.New(msg)
+	return newError(msg, arg0, args)
 }
 func (t *Timber) LogEx(extra map[string]interface{}, lvl Level, arg0 interface{}, args ...interface{}) {
-	t.prepareAndSendEx(lvl, extra, fmt.Sprintf(arg0.(string), args...), t.FileDepth)
+	if t.enabledHere(lvl) {
+		t.prepareAndSendEx(lvl, extra, formatMessage(arg0, args...), t.FileDepth)
+	}
+}
+
+func (t *Timber) FinestCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	if t.enabledHere(FINEST) {
+		t.prepareAndSendEx(FINEST, t.contextExtra(ctx), formatMessage(arg0, args...), t.FileDepth)
+	}
+}
+func (t *Timber) FineCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	if t.enabledHere(FINE) {
+		t.prepareAndSendEx(FINE, t.contextExtra(ctx), formatMessage(arg0, args...), t.FileDepth)
+	}
+}
+func (t *Timber) DebugCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSendEx(DEBUG, t.contextExtra(ctx), formatMessage(arg0, args...), t.FileDepth)
+	}
+}
+func (t *Timber) TraceCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	if t.enabledHere(TRACE) {
+		t.prepareAndSendEx(TRACE, t.contextExtra(ctx), formatMessage(arg0, args...), t.FileDepth)
+	}
+}
+func (t *Timber) InfoCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	if t.enabledHere(INFO) {
+		t.prepareAndSendEx(INFO, t.contextExtra(ctx), formatMessage(arg0, args...), t.FileDepth)
+	}
+}
+func (t *Timber) WarnCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error {
+	if !t.enabledHere(WARNING) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
+	t.prepareAndSendEx(WARNING, t.contextExtra(ctx), msg, t.FileDepth)
+	return newError(msg, arg0, args)
+}
+func (t *Timber) ErrorCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error {
+	if !t.enabledHere(ERROR) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
+	t.prepareAndSendEx(ERROR, t.contextExtra(ctx), msg, t.FileDepth)
+	return newError(msg, arg0, args)
+}
+func (t *Timber) CriticalCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error {
+	if !t.enabledHere(CRITICAL) {
+		return deferredError(arg0, args)
+	}
+	msg := formatMessage(arg0, args...)
+	t.prepareAndSendEx(CRITICAL, t.contextExtra(ctx), msg, t.FileDepth)
+	return newError(msg, arg0, args)
+}
+func (t *Timber) LogCtx(ctx context.Context, lvl Level, arg0 interface{}, args ...interface{}) {
+	if t.enabledHere(lvl) {
+		t.prepareAndSendEx(lvl, t.contextExtra(ctx), formatMessage(arg0, args...), t.FileDepth)
+	}
+}
+
+func (t *Timber) FinestKV(msg string, kv ...interface{}) {
+	if t.enabledHere(FINEST) {
+		t.prepareAndSendEx(FINEST, kvExtra(kv), msg, t.FileDepth)
+	}
+}
+func (t *Timber) FineKV(msg string, kv ...interface{}) {
+	if t.enabledHere(FINE) {
+		t.prepareAndSendEx(FINE, kvExtra(kv), msg, t.FileDepth)
+	}
+}
+func (t *Timber) DebugKV(msg string, kv ...interface{}) {
+	if t.enabledHere(DEBUG) {
+		t.prepareAndSendEx(DEBUG, kvExtra(kv), msg, t.FileDepth)
+	}
+}
+func (t *Timber) TraceKV(msg string, kv ...interface{}) {
+	if t.enabledHere(TRACE) {
+		t.prepareAndSendEx(TRACE, kvExtra(kv), msg, t.FileDepth)
+	}
+}
+func (t *Timber) InfoKV(msg string, kv ...interface{}) {
+	if t.enabledHere(INFO) {
+		t.prepareAndSendEx(INFO, kvExtra(kv), msg, t.FileDepth)
+	}
+}
+func (t *Timber) WarnKV(msg string, kv ...interface{}) error {
+	if t.enabledHere(WARNING) {
+		t.prepareAndSendEx(WARNING, kvExtra(kv), msg, t.FileDepth)
+	}
+	return errors.New(msg)
+}
+func (t *Timber) ErrorKV(msg string, kv ...interface{}) error {
+	if t.enabledHere(ERROR) {
+		t.prepareAndSendEx(ERROR, kvExtra(kv), msg, t.FileDepth)
+	}
+	return errors.New(msg)
+}
+func (t *Timber) CriticalKV(msg string, kv ...interface{}) error {
+	if t.enabledHere(CRITICAL) {
+		t.prepareAndSendEx(CRITICAL, kvExtra(kv), msg, t.FileDepth)
+	}
+	return errors.New(msg)
+}
+func (t *Timber) LogKV(lvl Level, msg string, kv ...interface{}) {
+	if t.enabledHere(lvl) {
+		t.prepareAndSendEx(lvl, kvExtra(kv), msg, t.FileDepth)
+	}
 }
 
 //
@@ -763,9 +1486,74 @@ func LogEx(extra map[string]interface{}, lvl Level, arg0 interface{}, args ...in
 	Global.LogEx(extra, lvl, arg0, args...)
 }
 
-func AddLogger(logger ConfigLogger) int { return Global.AddLogger(logger) }
-func Close()                            { Global.Close() }
+func FinestCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FinestCtx(ctx, arg0, args...)
+}
+func FineCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FineCtx(ctx, arg0, args...)
+}
+func DebugCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.DebugCtx(ctx, arg0, args...)
+}
+func TraceCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.TraceCtx(ctx, arg0, args...)
+}
+func InfoCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.InfoCtx(ctx, arg0, args...)
+}
+func WarnCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error {
+	return Global.WarnCtx(ctx, arg0, args...)
+}
+func ErrorCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error {
+	return Global.ErrorCtx(ctx, arg0, args...)
+}
+func CriticalCtx(ctx context.Context, arg0 interface{}, args ...interface{}) error {
+	return Global.CriticalCtx(ctx, arg0, args...)
+}
+func LogCtx(ctx context.Context, lvl Level, arg0 interface{}, args ...interface{}) {
+	Global.LogCtx(ctx, lvl, arg0, args...)
+}
+
+func FinestKV(msg string, kv ...interface{})         { Global.FinestKV(msg, kv...) }
+func FineKV(msg string, kv ...interface{})           { Global.FineKV(msg, kv...) }
+func DebugKV(msg string, kv ...interface{})          { Global.DebugKV(msg, kv...) }
+func TraceKV(msg string, kv ...interface{})          { Global.TraceKV(msg, kv...) }
+func InfoKV(msg string, kv ...interface{})           { Global.InfoKV(msg, kv...) }
+func WarnKV(msg string, kv ...interface{}) error     { return Global.WarnKV(msg, kv...) }
+func ErrorKV(msg string, kv ...interface{}) error    { return Global.ErrorKV(msg, kv...) }
+func CriticalKV(msg string, kv ...interface{}) error { return Global.CriticalKV(msg, kv...) }
+func LogKV(lvl Level, msg string, kv ...interface{}) { Global.LogKV(lvl, msg, kv...) }
+
+func AddLogger(logger ConfigLogger) int       { return Global.AddLogger(logger) }
+func RemoveLogger(handle int) error           { return Global.RemoveLogger(handle) }
+func LoggerByTag(tag string) (int, error)     { return Global.LoggerByTag(tag) }
+func Enabled(lvl Level) bool                  { return Global.Enabled(lvl) }
+func Flush(ctx context.Context) error         { return Global.Flush(ctx) }
+func AddContextExtractor(fn ContextExtractor) { Global.AddContextExtractor(fn) }
+func AddProcessor(p RecordProcessor)          { Global.AddProcessor(p) }
+func Close()                                  { Global.Close() }
+
+func SetRateLimit(limit RateLimit)                 { Global.SetRateLimit(limit) }
+func SetRateLimitFor(path string, limit RateLimit) { Global.SetRateLimitFor(path, limit) }
+
+// Package level Recover, recover has to be called here rather than by
+// Global.Recover
+func Recover(opts RecoverOptions) {
+	if r := recover(); r != nil {
+		Global.handlePanic(r, opts)
+	}
+}
+func Go(fn func(), opts ...RecoverOptions) { Global.Go(fn, opts...) }
+
+// Returns a child of Global with bound fields.  Its methods are called
+// directly rather than through these package functions so it looks one
+// frame less up the stack for the caller.
+func With(fields map[string]interface{}) *Timber {
+	child := Global.With(fields)
+	child.FileDepth--
+	return child
+}
 
-func LoadConfiguration(filename string)     { Global.LoadConfig(filename) }
-func LoadXMLConfiguration(filename string)  { Global.LoadXMLConfig(filename) }
-func LoadJSONConfiguration(filename string) { Global.LoadJSONConfig(filename) }
+func LoadConfiguration(filename string)     { printConfigError(Global.LoadConfig(filename)) }
+func LoadXMLConfiguration(filename string)  { printConfigError(Global.LoadXMLConfig(filename)) }
+func LoadJSONConfiguration(filename string) { printConfigError(Global.LoadJSONConfig(filename)) }
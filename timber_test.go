@@ -1,8 +1,19 @@
 package timber
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"log/slog"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"testing/slogtest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -142,3 +153,1158 @@ func TestJSONFormatterLogger(t *testing.T) {
 	a.Equal(mapExtra["testBool"], true)
 	a.Equal(mapExtra["testFloat"], 20.89)
 }
+
+func TestSetLevelAndFormatter(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	testWriter := new(TestWriter)
+	idx := log.AddLogger(ConfigLogger{
+		LogWriter: testWriter,
+		Level:     ERROR,
+		Formatter: NewPatFormatter("%L %M"),
+	})
+	log.Info("dropped")
+	a.Nil(log.SetLevel(idx, INFO))
+	a.Nil(log.SetFormatter(idx, NewPatFormatter("%l %M")))
+	log.Info("kept")
+
+	a.NotNil(log.SetLevel(idx+1, INFO))
+	a.NotNil(log.SetFormatter(-1, NewPatFormatter("%M")))
+	a.NotNil(log.SetLogger(idx+1, ConfigLogger{}))
+	log.Close()
+
+	a.Equal([]string{"INFO kept\n"}, testWriter.logs)
+	a.NotNil(log.SetLevel(idx, INFO))
+}
+
+// writer that blocks on the first write until released
+type blockingWriter struct {
+	TestWriter
+	started chan bool
+	release chan bool
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{started: make(chan bool), release: make(chan bool)}
+}
+
+func (bw *blockingWriter) LogWrite(msg string) {
+	if bw.started != nil {
+		close(bw.started)
+		bw.started = nil
+		<-bw.release
+	}
+	bw.TestWriter.LogWrite(msg)
+}
+
+func pad(msg string) string {
+	return msg + strings.Repeat(" ", 1000-len(msg))
+}
+
+func TestQueueOverflow(t *testing.T) {
+	a := assert.New(t)
+
+	for _, tt := range []struct {
+		cfg      QueueConfig
+		expected []string
+	}{
+		{QueueConfig{Policy: OverflowDropNewest, MaxRecords: 2}, []string{"first", "a", "b"}},
+		{QueueConfig{Policy: OverflowDropOldest, MaxRecords: 2}, []string{"first", "c", "d"}},
+		{QueueConfig{Policy: OverflowBlockTimeout, MaxRecords: 2, Timeout: time.Millisecond}, []string{"first", "a", "b"}},
+		// records are padded to 1000 bytes so only two fit
+		{QueueConfig{Policy: OverflowDropNewest, MaxBytes: 2*(recordOverhead+1000) + 600}, []string{"first", "a", "b"}},
+	} {
+		log := NewTimber()
+		writer := newBlockingWriter()
+		started := writer.started
+		log.AddLogger(ConfigLogger{LogWriter: writer, Level: DEBUG, Formatter: NewPatFormatter("%M"), Queue: tt.cfg})
+		log.Info("first")
+		<-started
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			log.Info(pad(msg))
+		}
+		// records are dropped by the asyncLumberJack so wait for it
+		for log.Dropped() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		close(writer.release)
+		log.Close()
+
+		a.Equal(uint64(2), log.Dropped())
+		expected := []string{}
+		for _, msg := range tt.expected {
+			if msg != "first" {
+				msg = pad(msg)
+			}
+			expected = append(expected, msg+"\n")
+		}
+		expected = append(expected, "TIMBER! 2 records dropped\n")
+		a.Equal(expected, writer.logs)
+	}
+}
+
+// The queue of the Timber fills up once the asyncLumberJack is blocked on a
+// logger queue with OverflowBlock
+func TestTimberQueueOverflow(t *testing.T) {
+	a := assert.New(t)
+
+	for _, tt := range []struct {
+		cfg      QueueConfig
+		expected []string
+	}{
+		{QueueConfig{Policy: OverflowDropNewest, MaxRecords: 2}, []string{"first", "a", "b", "c", "d"}},
+		{QueueConfig{Policy: OverflowDropOldest, MaxRecords: 2}, []string{"first", "a", "b", "e", "f"}},
+		{QueueConfig{Policy: OverflowBlockTimeout, MaxRecords: 2, Timeout: time.Millisecond}, []string{"first", "a", "b", "c", "d"}},
+		{QueueConfig{Policy: OverflowDropNewest, MaxBytes: 2*(recordOverhead+1000) + 600}, []string{"first", "a", "b", "c", "d"}},
+	} {
+		log := NewTimber()
+		log.SetQueueConfig(tt.cfg)
+		writer := newBlockingWriter()
+		started := writer.started
+		log.AddLogger(ConfigLogger{LogWriter: writer, Level: DEBUG, Formatter: NewPatFormatter("%M"),
+			Queue: QueueConfig{Policy: OverflowBlock, MaxRecords: 1}})
+		log.Info("first")
+		<-started
+		// "a" waits in the logger queue and the asyncLumberJack blocks on "b"
+		log.Info(pad("a"))
+		log.Info(pad("b"))
+		for queueLen(log.queue) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for _, msg := range []string{"c", "d", "e", "f"} {
+			log.Info(pad(msg))
+		}
+		close(writer.release)
+		log.Close()
+
+		a.Equal(uint64(2), log.Dropped())
+		expected := []string{}
+		for _, msg := range tt.expected {
+			if msg != "first" {
+				msg = pad(msg)
+			}
+			expected = append(expected, msg+"\n")
+		}
+		expected = append(expected, "TIMBER! 2 records dropped\n")
+		a.Equal(expected, writer.logs)
+	}
+}
+
+func queueLen(q *recordQueue) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.items)
+}
+
+func TestSlowWriterIsolation(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	slow := newBlockingWriter()
+	started := slow.started
+	fast := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: slow, Level: DEBUG, Formatter: NewPatFormatter("%M")})
+	h := log.AddLogger(ConfigLogger{LogWriter: fast, Level: DEBUG, Formatter: NewPatFormatter("%M")})
+	log.Info("one")
+	<-started
+	log.Info("two")
+	// replacing the fast writer waits for it to write everything and close
+	// while the slow one is still blocked
+	a.Nil(log.SetLogger(h, ConfigLogger{LogWriter: new(TestWriter), Level: DEBUG, Formatter: NewPatFormatter("%M")}))
+	a.Equal([]string{"one\n", "two\n"}, fast.logs)
+	close(slow.release)
+	log.Close()
+	a.Equal([]string{"one\n", "two\n"}, slow.logs)
+}
+
+func TestStuckWriter(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	stuck := newBlockingWriter()
+	started := stuck.started
+	fast := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: stuck, Level: DEBUG, Formatter: NewPatFormatter("%M")})
+	log.AddLogger(ConfigLogger{LogWriter: fast, Level: DEBUG, Formatter: NewPatFormatter("%M"),
+		Queue: QueueConfig{MaxRecords: 2000}})
+	log.Info("first")
+	<-started
+
+	const n = 1000
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < n; i++ {
+			log.Info("record %d", i)
+		}
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("callers blocked by a stuck writer")
+	}
+	// the stuck queue holds DefaultQueueSize records and drops the rest
+	dropped := n - DefaultQueueSize
+	for log.Dropped() < uint64(dropped) {
+		time.Sleep(time.Millisecond)
+	}
+	close(stuck.release)
+	log.Close()
+
+	a.Equal(n+1, len(fast.logs))
+	a.Equal(uint64(dropped), log.Dropped())
+	if a.Equal(DefaultQueueSize+2, len(stuck.logs)) {
+		a.Equal("first\n", stuck.logs[0])
+		a.Equal(fmt.Sprintf("record %d\n", DefaultQueueSize-1), stuck.logs[DefaultQueueSize])
+		a.Equal(fmt.Sprintf("TIMBER! %d records dropped\n", dropped), stuck.logs[DefaultQueueSize+1])
+	}
+}
+
+func TestSyncTimber(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	idx := log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewPatFormatter("%L %M")})
+	log.Info("one")
+	log.Debug("dropped")
+	// written without waiting for Close
+	a.Equal([]string{"INFO one\n"}, testWriter.logs)
+
+	a.Nil(log.SetLevel(idx, DEBUG))
+	a.NotNil(log.SetFormatter(idx+1, NewPatFormatter("%M")))
+	log.Debug("two")
+	a.Equal([]string{"INFO one\n", "DEBG two\n"}, testWriter.logs)
+
+	log.Close()
+	log.Info("closed")
+	a.Equal(2, len(testWriter.logs))
+	a.Equal(-1, log.AddLogger(ConfigLogger{LogWriter: testWriter}))
+}
+
+// writer that counts calls to Flush
+type flushingWriter struct {
+	TestWriter
+	flushes int
+}
+
+func (fw *flushingWriter) Flush() error {
+	fw.flushes++
+	return nil
+}
+
+func TestFlush(t *testing.T) {
+	a := assert.New(t)
+
+	for _, log := range []*Timber{NewTimber(), NewSyncTimber()} {
+		writer := new(flushingWriter)
+		log.AddLogger(ConfigLogger{LogWriter: writer, Level: DEBUG, Formatter: NewPatFormatter("%M")})
+		log.Info("one")
+		log.Info("two")
+		a.Nil(log.Flush(context.Background()))
+		a.Equal([]string{"one\n", "two\n"}, writer.logs)
+		a.Equal(1, writer.flushes)
+		log.Info("three")
+		log.Close()
+		a.Equal(3, len(writer.logs))
+		a.NotNil(log.Flush(context.Background()))
+	}
+}
+
+func TestFlushTimeout(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	writer := newBlockingWriter()
+	started := writer.started
+	log.AddLogger(ConfigLogger{LogWriter: writer, Level: DEBUG, Formatter: NewPatFormatter("%M")})
+	log.Info("stuck")
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	a.Equal(context.DeadlineExceeded, log.Flush(ctx))
+	close(writer.release)
+	log.Close()
+}
+
+func TestRemoveLogger(t *testing.T) {
+	a := assert.New(t)
+
+	for _, log := range []*Timber{NewTimber(), NewSyncTimber()} {
+		first := new(TestWriter)
+		second := new(TestWriter)
+		formatter := NewPatFormatter("%M")
+		h1 := log.AddLogger(ConfigLogger{LogWriter: first, Level: DEBUG, Formatter: formatter})
+		h2 := log.AddLogger(ConfigLogger{LogWriter: second, Level: DEBUG, Formatter: formatter})
+		log.Info("both")
+		a.Nil(log.RemoveLogger(h1))
+		a.Equal([]string{"both\n"}, first.logs)
+		a.NotNil(log.RemoveLogger(h1))
+		a.NotNil(log.SetLevel(h1, ERROR))
+
+		// the second handle still works after the first is removed
+		a.Nil(log.SetLevel(h2, ERROR))
+		log.Info("dropped")
+		log.Error("second")
+		h3 := log.AddLogger(ConfigLogger{LogWriter: first, Level: DEBUG, Formatter: formatter})
+		a.NotEqual(h1, h3)
+		log.Close()
+		a.Equal([]string{"both\n", "second\n"}, second.logs)
+	}
+}
+
+func TestLoggerByTag(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	// the log methods are called directly, not through the package functions
+	log.FileDepth = DefaultFileDepth - 1
+	a.Nil(log.LoadXMLConfig("timber.xml"))
+	h, err := log.LoggerByTag("file")
+	a.Nil(err)
+	a.Equal(1, h)
+	a.Nil(log.SetLevel(h, ERROR))
+	_, err = log.LoggerByTag("missing")
+	a.NotNil(err)
+
+	testWriter := new(TestWriter)
+	_, err = log.LoggerByTag("test")
+	a.NotNil(err)
+	h = log.AddLogger(ConfigLogger{Tag: "test", LogWriter: testWriter, Level: ERROR, Formatter: NewPatFormatter("%M")})
+	found, err := log.LoggerByTag("test")
+	a.Nil(err)
+	a.Equal(h, found)
+	a.Nil(log.SetGranulars(found, map[string]Level{"github.com/cocoonlife/timber": DEBUG}))
+	log.Info("granular")
+	log.Close()
+	a.Equal([]string{"granular\n"}, testWriter.logs)
+}
+
+// Decodes a record written by a JSONFormatter
+func decodeRecord(t *testing.T, msg string) LogRecord {
+	var rec LogRecord
+	assert.Nil(t, json.Unmarshal([]byte(msg), &rec), msg)
+	return rec
+}
+
+func decodeRecords(t *testing.T, logs []string) []LogRecord {
+	var recs []LogRecord
+	for _, msg := range logs {
+		recs = append(recs, decodeRecord(t, msg))
+	}
+	return recs
+}
+
+func TestWith(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	// the log methods are called directly, not through the package functions
+	log.FileDepth = DefaultFileDepth - 1
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: DEBUG, Formatter: NewJSONFormatter()})
+
+	child := log.With(map[string]interface{}{"request": "abc", "user": 1})
+	grandchild := child.With(map[string]interface{}{"user": 2, "step": "load"})
+	var logger Logger = grandchild
+	_, _, line, _ := runtime.Caller(0)
+	logger.InfoEx(map[string]interface{}{"step": "save"}, "nested")
+	child.Info("child")
+	log.Info("parent")
+	log.Close()
+
+	recs := decodeRecords(t, testWriter.logs)
+	a.Equal(map[string]interface{}{"request": "abc", "user": float64(2), "step": "save"}, recs[0].Extra)
+	a.Equal(map[string]interface{}{"request": "abc", "user": float64(1)}, recs[1].Extra)
+	a.Nil(recs[2].Extra)
+	a.Equal(line+1, recs[0].SourceLine)
+	a.True(strings.HasSuffix(recs[0].SourceFile, "timber_test.go"))
+}
+
+type ctxKey string
+
+func TestContextExtractors(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: DEBUG, Formatter: NewJSONFormatter()})
+	log.AddContextExtractor(func(ctx context.Context, extra map[string]interface{}) {
+		if id, ok := ctx.Value(ctxKey("request")).(string); ok {
+			extra["request"] = id
+		}
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey("request"), "abc")
+	log.With(map[string]interface{}{"user": "bob"}).InfoCtx(ctx, "with %s", "context")
+	err := log.ErrorCtx(context.Background(), "no values")
+	a.Equal("no values", err.Error())
+	log.Close()
+
+	first, second := decodeRecord(t, testWriter.logs[0]), decodeRecord(t, testWriter.logs[1])
+	a.Equal("with context", first.Message)
+	a.Equal(map[string]interface{}{"request": "abc", "user": "bob"}, first.Extra)
+	a.Equal(ERROR, second.Level)
+	a.Nil(second.Extra)
+}
+
+// counts how many times it is formatted
+type countingStringer int
+
+func (c *countingStringer) String() string {
+	*c++
+	return "counted"
+}
+
+func TestEnabled(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	a.False(log.Enabled(CRITICAL))
+	h := log.AddLogger(ConfigLogger{LogWriter: new(TestWriter), Level: INFO, Formatter: NewPatFormatter("%M")})
+	a.True(log.Enabled(INFO))
+	a.False(log.Enabled(DEBUG))
+
+	var count countingStringer
+	log.Debug("%v", &count)
+	a.Equal(countingStringer(0), count)
+	log.Info("%v", &count)
+	a.Equal(countingStringer(1), count)
+
+	a.Nil(log.SetGranulars(h, map[string]Level{"some/package": FINE}))
+	a.True(log.Enabled(FINE))
+	a.False(log.Enabled(FINEST))
+	a.Nil(log.SetLevel(h, NONE))
+	a.True(log.Enabled(FINEST))
+	a.Nil(log.RemoveLogger(h))
+	a.False(log.Enabled(CRITICAL))
+	log.Close()
+}
+
+func BenchmarkDisabledDebug(b *testing.B) {
+	log := NewTimber()
+	log.AddLogger(ConfigLogger{LogWriter: new(TestWriter), Level: INFO, Formatter: NewPatFormatter("%M")})
+	for i := 0; i < b.N; i++ {
+		log.Debug("not logged %d", i)
+	}
+	log.Close()
+}
+
+func TestLogAfterReconfigure(t *testing.T) {
+	a := assert.New(t)
+
+	// the gate must be updated before AddLogger and SetLevel return
+	for i := 0; i < 200; i++ {
+		log := NewTimber()
+		testWriter := new(TestWriter)
+		h := log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewPatFormatter("%M")})
+		log.Info("added")
+		a.Nil(log.SetLevel(h, DEBUG))
+		log.Debug("lowered")
+		replaced := new(TestWriter)
+		a.Nil(log.SetLogger(h, ConfigLogger{LogWriter: replaced, Level: FINE, Formatter: NewPatFormatter("%M")}))
+		log.Fine("replaced")
+		log.Close()
+		a.Equal([]string{"added\n", "lowered\n"}, testWriter.logs)
+		a.Equal([]string{"replaced\n"}, replaced.logs)
+	}
+}
+
+func TestNonStringArgs(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewPatFormatter("%M")})
+
+	err := errors.New("100% broken")
+	a.Equal(err, log.Error(err))
+	var count countingStringer
+	log.Info(&count)
+	log.Infof(42, "is", "the answer")
+	log.WarnEx(nil, err, "again")
+
+	called := 0
+	closure := func() string {
+		called++
+		return "closure"
+	}
+	log.Debug(closure)
+	a.Equal(0, called)
+	log.LogCtx(context.Background(), DEBUG, closure)
+	// nothing is enabled without loggers
+	lazy := NewSyncTimber().WarnEx(nil, closure)
+	a.Equal(0, called)
+	a.Equal("closure", lazy.Error())
+	a.Equal(1, called)
+	log.Critical(closure)
+	a.Equal(2, called)
+	log.Close()
+
+	a.Equal([]string{"100% broken\n", "counted\n", "42 is the answer\n", "100% broken again\n", "closure\n"}, testWriter.logs)
+}
+
+func TestKV(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewJSONFormatter()})
+
+	log.InfoKV("done", "user", "bob", "latency", 3)
+	a.Equal("failed", log.ErrorKV("failed", 7, "seven", "dangling").Error())
+	log.With(map[string]interface{}{"user": "alice", "app": "x"}).WarnKV("bound", "user", "carol")
+	log.InfoKV("plain")
+	a.Equal(0.0, testing.AllocsPerRun(10, func() { log.DebugKV("skipped", "user", "bob") }))
+	log.Close()
+
+	recs := decodeRecords(t, testWriter.logs)
+	a.Equal(4, len(recs))
+	a.Equal("done", recs[0].Message)
+	a.Equal(map[string]interface{}{"user": "bob", "latency": float64(3)}, recs[0].Extra)
+	a.Equal(map[string]interface{}{"7": "seven", BadKey: "dangling"}, recs[1].Extra)
+	a.Equal(ERROR, recs[1].Level)
+	a.Equal(map[string]interface{}{"user": "carol", "app": "x"}, recs[2].Extra)
+	a.Nil(recs[3].Extra)
+}
+
+func TestSlogHandler(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	h := log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: DEBUG, Formatter: NewJSONFormatter()})
+	logger := slog.New(NewSlogHandler(log.With(map[string]interface{}{"app": "test"})))
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Info("hello", "user", "bob", slog.Group("req", "id", 7))
+	logger.With("a", 1).WithGroup("g").With("b", 2).Warn("grouped", "c", 3)
+	logger.Debug("debug")
+	logger.Log(context.Background(), slog.LevelDebug-4, "fine")
+	a.False(logger.Enabled(context.Background(), slog.LevelDebug-4))
+
+	// granulars see the function of the slog caller
+	a.Nil(log.SetGranulars(h, map[string]Level{"github.com/cocoonlife/timber.TestSlogHandler": ERROR}))
+	logger.Warn("filtered")
+	logger.Error("kept")
+	log.Close()
+
+	recs := decodeRecords(t, testWriter.logs)
+	a.Equal(4, len(recs))
+	a.Equal(INFO, recs[0].Level)
+	a.Equal(map[string]interface{}{"app": "test", "user": "bob", "req": map[string]interface{}{"id": float64(7)}}, recs[0].Extra)
+	a.Equal(line+1, recs[0].SourceLine)
+	a.True(strings.HasSuffix(recs[0].SourceFile, "timber_test.go"))
+	a.Equal("github.com/cocoonlife/timber.TestSlogHandler", recs[0].FuncPath)
+	a.Equal("github.com/cocoonlife/timber", recs[0].PackagePath)
+	a.Equal(WARNING, recs[1].Level)
+	a.Equal(map[string]interface{}{"app": "test", "a": float64(1), "g": map[string]interface{}{"b": float64(2), "c": float64(3)}}, recs[1].Extra)
+	a.Equal(DEBUG, recs[2].Level)
+	a.Equal("kept", recs[3].Message)
+}
+
+func TestSlogHandlerConformance(t *testing.T) {
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: FINEST, Formatter: NewJSONFormatter()})
+	err := slogtest.TestHandler(NewSlogHandler(log), func() []map[string]any {
+		var results []map[string]any
+		for _, rec := range decodeRecords(t, testWriter.logs) {
+			m := map[string]any{slog.LevelKey: rec.Level, slog.MessageKey: rec.Message}
+			if !rec.Timestamp.IsZero() {
+				m[slog.TimeKey] = rec.Timestamp
+			}
+			for k, v := range rec.Extra {
+				m[k] = v
+			}
+			results = append(results, m)
+		}
+		return results
+	})
+	assert.Nil(t, err)
+	log.Close()
+}
+
+func TestWrite(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: DEBUG, Formatter: NewPatFormatter("%L|%S|%M")})
+	std := stdlog.New(log, "", 0)
+
+	_, _, line, _ := runtime.Caller(0)
+	std.Printf("[ERROR] disk full")
+	std.Output(1, "warning: multi\nline")
+	std.Print("  debug: indented")
+	std.Print("plain")
+	std.Print("[FINEST] filtered")
+	fmt.Fprint(log, "partial ")
+	fmt.Fprint(log, "line\nleft")
+	a.Equal(5, len(testWriter.logs))
+	log.Close()
+	a.Equal(6, len(testWriter.logs))
+
+	expect := []string{
+		fmt.Sprintf("EROR|timber_test.go:%d|[ERROR] disk full", line+1),
+		fmt.Sprintf("WARN|timber_test.go:%d|warning: multi\nline", line+2),
+		fmt.Sprintf("DEBG|timber_test.go:%d|  debug: indented", line+3),
+		fmt.Sprintf("INFO|timber_test.go:%d|plain", line+4),
+		fmt.Sprintf("INFO|timber_test.go:%d|partial line", line+7),
+		fmt.Sprintf("INFO|timber_test.go:%d|left", line+9), // logged by Close
+	}
+	for i, msg := range testWriter.logs {
+		// drop the directory of the source file
+		lvl, rest, _ := strings.Cut(msg, "|")
+		_, rest, _ = strings.Cut(rest, "timber_test.go:")
+		a.Equal(expect[i]+"\n", lvl+"|timber_test.go:"+rest)
+	}
+}
+
+func TestStack(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	log.FileDepth = DefaultFileDepth - 1
+	log.StackLevel = ERROR
+	jsonWriter := new(TestWriter)
+	patWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: jsonWriter, Level: INFO, Formatter: NewJSONFormatter()})
+	log.AddLogger(ConfigLogger{LogWriter: patWriter, Level: INFO, Formatter: NewPatFormatter("%M%B")})
+
+	_, file, line, _ := runtime.Caller(0)
+	log.Error("with stack")
+	log.Warn("without")
+	log.WarnEx(map[string]interface{}{StackExtra: true, "user": "bob"}, "forced")
+	log.Close()
+
+	recs := decodeRecords(t, jsonWriter.logs)
+	a.Equal(3, len(recs))
+	a.Equal(StackFrame{Function: "github.com/cocoonlife/timber.TestStack", File: file, Line: line + 1}, recs[0].Stack[0])
+	a.Equal("testing.tRunner", recs[0].Stack[1].Function)
+	a.Nil(recs[1].Stack)
+	a.NotContains(jsonWriter.logs[1], "stack")
+	a.Equal(line+3, recs[2].Stack[0].Line)
+	a.Equal(map[string]interface{}{"user": "bob"}, recs[2].Extra)
+
+	a.True(strings.HasPrefix(patWriter.logs[0],
+		fmt.Sprintf("with stack\n\tgithub.com/cocoonlife/timber.TestStack\n\t\t%s:%d\n\ttesting.tRunner\n", file, line+1)))
+	a.Equal("without\n", patWriter.logs[1])
+
+	// slog and Write callers get a stack starting at their own frame too
+	log = NewSyncTimber()
+	log.StackLevel = ERROR
+	jsonWriter = new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: jsonWriter, Level: INFO, Formatter: NewJSONFormatter()})
+	slogger := slog.New(NewSlogHandler(log))
+	std := stdlog.New(log, "", 0)
+
+	_, file, line, _ = runtime.Caller(0)
+	slogger.Error("slog")
+	std.Print("panic: write")
+	slogger.Warn("slog forced", StackExtra, true)
+	std.Print("warning: write")
+	log.Close()
+
+	recs = decodeRecords(t, jsonWriter.logs)
+	a.Equal(4, len(recs))
+	for i := 0; i < 3; i++ {
+		if a.NotEmpty(recs[i].Stack, recs[i].Message) {
+			a.Equal(StackFrame{Function: "github.com/cocoonlife/timber.TestStack", File: file, Line: line + 1 + i}, recs[i].Stack[0])
+		}
+	}
+	a.Nil(recs[2].Extra)
+	a.Nil(recs[3].Stack)
+}
+
+func TestRecover(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewJSONFormatter()})
+
+	var line int
+	func() {
+		defer log.Recover(RecoverOptions{Action: PanicSwallow})
+		var m map[string]int
+		_, _, line, _ = runtime.Caller(0)
+		m["nil"] = 1
+	}()
+	a.PanicsWithValue("boom", func() {
+		defer log.Recover(RecoverOptions{})
+		panic("boom")
+	})
+	func() {
+		defer log.Recover(RecoverOptions{})
+	}()
+
+	bw := newBlockingWriter()
+	log.AddLogger(ConfigLogger{LogWriter: bw, Level: INFO, Formatter: NewPatFormatter("%M")})
+	started := bw.started
+	log.Go(func() { panic("in goroutine") }, RecoverOptions{Action: PanicSwallow})
+	<-started
+	close(bw.release)
+	log.Close()
+
+	recs := decodeRecords(t, testWriter.logs)
+	a.Equal(3, len(recs))
+	a.Equal(CRITICAL, recs[0].Level)
+	a.Equal("panic: assignment to entry in nil map", recs[0].Message)
+	a.Equal(line+1, recs[0].SourceLine)
+	a.Equal("github.com/cocoonlife/timber.TestRecover.func1", recs[0].Stack[0].Function)
+	a.Equal("panic: boom", recs[1].Message)
+	a.Equal("panic: in goroutine", recs[2].Message)
+	a.Equal([]string{"panic: in goroutine\n"}, bw.logs)
+}
+
+func rateLimitedWarn(log *Timber, i int) {
+	log.Warn("hot %d", i)
+}
+
+func TestRateLimit(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewSyncTimber()
+	log.FileDepth = DefaultFileDepth - 1
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewPatFormatter("%M")})
+	log.SetRateLimit(RateLimit{Records: 1, Interval: 20 * time.Millisecond, Burst: 2})
+	log.SetRateLimitFor("github.com/cocoonlife/timber.rateLimitedWarn", RateLimit{Records: 1, Interval: time.Hour})
+
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 10; i++ {
+			log.Info("loop %d", i)
+			rateLimitedWarn(log, i)
+		}
+		// enough for one more record from the loop
+		time.Sleep(30 * time.Millisecond)
+	}
+	log.Close()
+
+	a.Equal([]string{
+		"loop 0\n", "hot 0\n", "loop 1\n",
+		"TIMBER! suppressed 8 similar messages\n", "loop 0\n",
+	}, testWriter.logs)
+}
+
+// writer that passes each message on to a channel
+type channelWriter chan string
+
+func (cw channelWriter) LogWrite(msg string) { cw <- msg }
+func (cw channelWriter) Close()              {}
+
+func TestCollapse(t *testing.T) {
+	a := assert.New(t)
+
+	for _, log := range []*Timber{NewTimber(), NewSyncTimber()} {
+		console := make(channelWriter, 10)
+		audit := new(TestWriter)
+		log.AddLogger(ConfigLogger{LogWriter: console, Level: INFO, Formatter: NewJSONFormatter(), Collapse: 50 * time.Millisecond})
+		log.AddLogger(ConfigLogger{LogWriter: audit, Level: INFO, Formatter: NewPatFormatter("%M")})
+
+		for i := 0; i < 5; i++ {
+			log.Info("same")
+		}
+		log.Info("other")
+		for i := 0; i < 3; i++ {
+			log.Info("again")
+		}
+		// only records with the same fields are collapsed
+		for _, user := range []int{1, 2, 2} {
+			log.InfoKV("login", "user", user)
+		}
+
+		var recs []LogRecord
+		for len(recs) < 8 {
+			select {
+			case msg := <-console:
+				recs = append(recs, decodeRecord(t, msg))
+			case <-time.After(time.Second):
+				a.Fail("missing records", "got %d", len(recs))
+				return
+			}
+		}
+		log.Close()
+
+		a.Equal("same", recs[0].Message)
+		a.Nil(recs[0].Extra)
+		a.Equal("same", recs[1].Message)
+		a.Equal(map[string]interface{}{RepeatedExtra: float64(4)}, recs[1].Extra)
+		a.Equal("other", recs[2].Message)
+		a.Equal("again", recs[3].Message)
+		a.Equal("again", recs[4].Message)
+		a.Equal(map[string]interface{}{RepeatedExtra: float64(2)}, recs[4].Extra)
+		a.Equal(map[string]interface{}{"user": float64(1)}, recs[5].Extra)
+		a.Equal(map[string]interface{}{"user": float64(2)}, recs[6].Extra)
+		// written at the end of the window
+		a.Equal("login", recs[7].Message)
+		a.Equal(map[string]interface{}{"user": float64(2), RepeatedExtra: float64(1)}, recs[7].Extra)
+		a.Equal(12, len(audit.logs))
+	}
+
+	// records logged far apart are not merged when they are delivered
+	// together, and repeats within the window are
+	writer := new(TestWriter)
+	cfg := ConfigLogger{LogWriter: writer, Formatter: NewPatFormatter("%M"), Collapse: time.Minute}
+	var c collapser
+	start := time.Now()
+	for _, offset := range []time.Duration{0, 2 * time.Minute, 2*time.Minute + time.Second} {
+		c.write(&cfg, &LogRecord{Message: "late", Timestamp: start.Add(offset)}, time.NewTimer)
+	}
+	a.Equal([]string{"late\n", "late\n"}, writer.logs)
+	a.Equal(1, c.repeated)
+	c.flush(&cfg)
+	a.Equal(3, len(writer.logs))
+}
+
+func TestProcessors(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	log.AddProcessor(RecordProcessorFunc(func(rec *LogRecord) bool {
+		if rec.Message == "health check" {
+			return false
+		}
+		rec.Extra["version"] = "1.2"
+		return true
+	}))
+	upper := new(TestWriter)
+	plain := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: upper, Level: INFO, Formatter: NewJSONFormatter(),
+		Processors: []RecordProcessor{RecordProcessorFunc(func(rec *LogRecord) bool {
+			rec.Message = strings.ToUpper(rec.Message)
+			rec.Extra["upper"] = true
+			return true
+		})}})
+	log.AddLogger(ConfigLogger{LogWriter: plain, Level: INFO, Formatter: NewJSONFormatter()})
+
+	extra := map[string]interface{}{"user": "bob"}
+	log.InfoEx(extra, "hello")
+	log.Info("health check")
+	log.Info("no extra")
+	log.Close()
+	a.Equal(map[string]interface{}{"user": "bob"}, extra)
+
+	recs := decodeRecords(t, append(upper.logs, plain.logs...))
+	a.Equal(4, len(recs))
+	a.Equal("HELLO", recs[0].Message)
+	a.Equal(map[string]interface{}{"user": "bob", "version": "1.2", "upper": true}, recs[0].Extra)
+	a.Equal("NO EXTRA", recs[1].Message)
+	a.Equal("hello", recs[2].Message)
+	a.Equal(map[string]interface{}{"user": "bob", "version": "1.2"}, recs[2].Extra)
+	a.Equal(map[string]interface{}{"version": "1.2"}, recs[3].Extra)
+}
+
+func TestRedact(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	xmlConfig := `<logging>
+  <filter enabled="true"><tag>file</tag><type>file</type><level>INFO</level>
+    <property name="filename">` + dir + `/xml.log</property></filter>
+  <redact><key>Password</key><rule>emails</rule><pattern>tok-[0-9]+</pattern><mask>***</mask></redact>
+</logging>`
+	jsonConfig := `{"filters": [{"enabled": true, "tag": "file", "type": "file", "level": "INFO",
+    "properties": [{"name": "filename", "value": "` + dir + `/json.log"}]}],
+  "redact": {"keys": ["password"], "rules": ["emails"], "patterns": ["tok-[0-9]+"], "mask": "***"}}`
+	a.Nil(os.WriteFile(dir+"/timber.xml", []byte(xmlConfig), 0644))
+	a.Nil(os.WriteFile(dir+"/timber.json", []byte(jsonConfig), 0644))
+
+	for _, name := range []string{"xml", "json"} {
+		log := NewTimber()
+		if name == "xml" {
+			a.Nil(log.LoadXMLConfig(dir + "/timber.xml"))
+		} else {
+			a.Nil(log.LoadJSONConfig(dir + "/timber.json"))
+		}
+		h, err := log.LoggerByTag("file")
+		a.Nil(err)
+		a.Nil(log.SetFormatter(h, NewJSONFormatter()))
+		extra := map[string]interface{}{"password": "hunter2", "nested": map[string]interface{}{"PASSWORD": "x", "token": "tok-42"}}
+		log.InfoEx(extra, "mail bob@example.com with tok-123")
+		log.Close()
+		a.Equal("hunter2", extra["password"])
+
+		data, err := os.ReadFile(dir + "/" + name + ".log")
+		a.Nil(err)
+		rec := decodeRecord(t, string(data))
+		a.Equal("mail *** with ***", rec.Message)
+		a.Equal(map[string]interface{}{"password": "***", "nested": map[string]interface{}{"PASSWORD": "***", "token": "***"}}, rec.Extra)
+	}
+
+	a.Nil(os.WriteFile(dir+"/bad.xml", []byte(`<logging><redact><rule>phones</rule></redact></logging>`), 0644))
+	a.Nil(os.WriteFile(dir+"/badfilter.xml", []byte(`<logging><redact><key>password</key></redact>
+		<filter enabled="true"><type>console</type><level>WARNIGN</level></filter></logging>`), 0644))
+	log := NewTimber()
+	a.NotNil(log.LoadXMLConfig(dir + "/bad.xml"))
+	a.NotNil(log.LoadXMLConfig(dir + "/badfilter.xml"))
+	a.Equal(0, len(log.processors.list))
+	// loading again replaces the redactor rather than adding another
+	a.Nil(log.LoadConfig(dir + "/timber.xml"))
+	a.Nil(log.LoadConfig(dir + "/timber.json"))
+	a.Equal(1, len(log.processors.list))
+	log.Close()
+	a.Equal("call [REDACTED] now", NewRedactor(nil, RedactCardNumbers).redactString("call 4111 1111 1111 1111 now"))
+}
+
+const AUDIT Level = 20
+
+func init() {
+	RegisterLevel("AUDIT", "AUDT", AUDIT)
+}
+
+func TestRegisterLevel(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(AUDIT, GetLevel("AUDIT"))
+	a.Equal("AUDIT", LevelName(AUDIT))
+	a.Equal("AUDT", ShortLevelName(AUDIT))
+	a.Equal("INFO", LevelName(INFO))
+	a.Equal("99", ShortLevelName(99))
+	a.Panics(func() { RegisterLevel("AUDIT", "AUD2", 21) })
+	a.Panics(func() { RegisterLevel("AUDIT2", "AUD2", AUDIT) })
+	a.Panics(func() { RegisterLevel("NOTICE", "NOTC", WARNING) })
+	a.Panics(func() { RegisterLevel("NOTICE", "WARN", 21) })
+	a.Panics(func() { RegisterLevel("NOTICE", "eror", 21) })
+	a.Panics(func() { RegisterLevel("NOTICE", "AUDT", 21) })
+	a.Panics(func() { RegisterLevel("notice", "info", 21) })
+	a.Panics(func() { RegisterLevel("audit", "NOTC", 21) })
+
+	dir := t.TempDir()
+	xmlConfig := `<logging><filter enabled="true"><tag>file</tag><type>file</type><level>AUDIT</level>
+    <property name="filename">` + dir + `/audit.log</property></filter></logging>`
+	a.Nil(os.WriteFile(dir+"/timber.xml", []byte(xmlConfig), 0644))
+
+	log := NewTimber()
+	a.Nil(log.LoadXMLConfig(dir + "/timber.xml"))
+	testWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewPatFormatter("%L %l %M")})
+	jsonWriter := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: jsonWriter, Level: INFO, Formatter: NewJSONFormatter()})
+	log.Critical("critical")
+	log.Log(AUDIT, "audited")
+	log.Log(99, "unnamed")
+	log.Close()
+
+	a.Equal([]string{"CRIT CRITICAL critical\n", "AUDT AUDIT audited\n", "99 99 unnamed\n"}, testWriter.logs)
+	rec := decodeRecord(t, jsonWriter.logs[1])
+	a.Equal(AUDIT, rec.Level)
+	data, err := os.ReadFile(dir + "/audit.log")
+	a.Nil(err)
+	a.Equal("audited\nunnamed\n", string(data))
+}
+
+func TestParseLevel(t *testing.T) {
+	a := assert.New(t)
+
+	for str, expected := range map[string]Level{
+		"WARNING": WARNING, "warning": WARNING, "WARN": WARNING, "eror": ERROR,
+		" debug ": DEBUG, "Audit": AUDIT, "audt": AUDIT, "3": DEBUG, "42": Level(42),
+	} {
+		lvl, err := ParseLevel(str)
+		a.Nil(err, str)
+		a.Equal(expected, lvl, str)
+	}
+	for _, str := range []string{"", "WARNINGS", "verbose", "1.5"} {
+		_, err := ParseLevel(str)
+		a.NotNil(err, str)
+	}
+
+	// a valid filter before the bad one must not be installed either
+	dir := t.TempDir()
+	configs := map[string]string{
+		"filter.xml": `<logging><filter enabled="true"><tag>ok</tag><type>console</type><level>INFO</level></filter>
+			<filter enabled="true"><tag>typo</tag><type>console</type><level>WARNIGN</level></filter></logging>`,
+		"granular.xml": `<logging><filter enabled="true"><tag>ok</tag><type>console</type><level>INFO</level></filter>
+			<filter enabled="true"><tag>typo</tag><type>console</type><level>INFO</level>
+			<granular><level>verbose</level><path>some/package</path></granular></filter></logging>`,
+		"filter.json": `{"filters": [{"enabled": true, "tag": "ok", "type": "console", "level": "INFO"},
+			{"enabled": true, "tag": "typo", "type": "console", "level": "WARNIGN"}]}`,
+		"granular.json": `{"filters": [{"enabled": true, "tag": "ok", "type": "console", "level": "INFO"},
+			{"enabled": true, "tag": "typo", "type": "console", "level": "info", "granulars": [{"level": "verbose", "path": "some/package"}]}]}`,
+	}
+	for name, config := range configs {
+		a.Nil(os.WriteFile(dir+"/"+name, []byte(config), 0644))
+		log := NewTimber()
+		err := log.LoadConfig(dir + "/" + name)
+		if a.NotNil(err, name) {
+			a.Contains(err.Error(), `"typo"`, name)
+			if strings.HasPrefix(name, "granular") {
+				a.Contains(err.Error(), `"some/package"`, name)
+			}
+		}
+		_, err = log.LoggerByTag("ok")
+		a.NotNil(err, name)
+		a.False(log.Enabled(CRITICAL), name)
+		log.Close()
+	}
+	log := NewTimber()
+	a.NotNil(log.LoadConfig(dir + "/timber.yaml"))
+	a.NotNil(log.LoadConfig(dir + "/timber"))
+	log.Close()
+}
+
+func mutedHelper(log *Timber, count *countingStringer) {
+	log.Info("muted %v", count)
+}
+
+func TestOffGranular(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	xmlConfig := `<logging><filter enabled="true"><tag>test</tag><type>console</type><level>INFO</level>
+    <granular><level>OFF</level><path>github.com/cocoonlife/timber.mutedHelper</path></granular></filter></logging>`
+	a.Nil(os.WriteFile(dir+"/timber.xml", []byte(xmlConfig), 0644))
+
+	log := NewTimber()
+	log.FileDepth = DefaultFileDepth - 1
+	a.Nil(log.LoadXMLConfig(dir + "/timber.xml"))
+	h, err := log.LoggerByTag("test")
+	a.Nil(err)
+	testWriter := new(TestWriter)
+	a.Nil(log.SetLogger(h, ConfigLogger{LogWriter: testWriter, Level: INFO, Formatter: NewPatFormatter("%M"),
+		Granulars: map[string]Level{"github.com/cocoonlife/timber.mutedHelper": OFF}}))
+
+	var count countingStringer
+	mutedHelper(log, &count)
+	mutedHelper(log, &count)
+	log.Critical("not muted")
+	a.Equal(countingStringer(0), count)
+
+	// a second logger that does not mute the helper
+	other := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: other, Level: INFO, Formatter: NewPatFormatter("%M")})
+	mutedHelper(log, &count)
+	a.Equal(countingStringer(1), count)
+	a.Nil(log.SetLevel(h, OFF))
+	log.Critical("only other")
+	log.Close()
+
+	a.Equal([]string{"not muted\n"}, testWriter.logs)
+	a.Equal([]string{"muted counted\n", "only other\n"}, other.logs)
+	a.Equal("OFF", LevelName(OFF))
+	a.Equal(OFF, GetLevel("OFF"))
+	a.Panics(func() { RegisterLevel("HUGE", "HUGE", OFF) })
+}
+
+func TestMaxLevel(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	xmlFilter := `<filter enabled="true"><type>file</type><level>%s</level>%s
+		<property name="filename">%s</property><property name="format">%%L %%M</property></filter>`
+	jsonFilter := `{"enabled": true, "type": "file", "level": "%s"%s,
+		"properties": [{"name": "filename", "value": "%s"}, {"name": "format", "value": "%%L %%M"}]}`
+	configs := map[string]string{
+		"timber.xml": "<logging>" + fmt.Sprintf(xmlFilter, "INFO", `<maxlevel>WARNING</maxlevel>
+			<granular><level>FINEST</level><path>github.com/cocoonlife/timber</path></granular>`, dir+"/out.xml.log") +
+			fmt.Sprintf(xmlFilter, "ERROR", "", dir+"/err.xml.log") + "</logging>",
+		"timber.json": `{"filters": [` + fmt.Sprintf(jsonFilter, "INFO", `, "maxLevel": "WARNING",
+			"granulars": [{"level": "FINEST", "path": "github.com/cocoonlife/timber"}]`, dir+"/out.json.log") + "," +
+			fmt.Sprintf(jsonFilter, "ERROR", "", dir+"/err.json.log") + "]}",
+	}
+	for name, config := range configs {
+		a.Nil(os.WriteFile(dir+"/"+name, []byte(config), 0644))
+		log := NewTimber()
+		log.FileDepth = DefaultFileDepth - 1
+		a.Nil(log.LoadConfig(dir+"/"+name), name)
+		log.Debug("debug")
+		log.Warn("warn")
+		log.Error("error")
+		log.Critical("critical")
+		log.Close()
+
+		ext := name[len("timber"):]
+		out, err := os.ReadFile(dir + "/out" + ext + ".log")
+		a.Nil(err, name)
+		// the granular lets DEBUG through but not above the maximum
+		a.Equal("DEBG debug\nWARN warn\n", string(out), name)
+		out, err = os.ReadFile(dir + "/err" + ext + ".log")
+		a.Nil(err, name)
+		a.Equal("EROR error\nCRIT critical\n", string(out), name)
+	}
+
+	bad := map[string]string{
+		"bad.xml":  `<logging><filter enabled="true"><tag>typo</tag><type>console</type><maxlevel>WARNIGN</maxlevel></filter></logging>`,
+		"bad.json": `{"filters": [{"enabled": true, "tag": "typo", "type": "console", "maxLevel": "WARNIGN"}]}`,
+	}
+	for name, config := range bad {
+		a.Nil(os.WriteFile(dir+"/"+name, []byte(config), 0644))
+		log := NewTimber()
+		err := log.LoadConfig(dir + "/" + name)
+		if a.NotNil(err, name) {
+			a.Contains(err.Error(), `"typo"`, name)
+		}
+		log.Close()
+	}
+}
+
+func TestRecordFilters(t *testing.T) {
+	a := assert.New(t)
+
+	log := NewTimber()
+	log.Hostname = func() string { return "web-1" }
+	audit := new(TestWriter)
+	console := new(TestWriter)
+	other := new(TestWriter)
+	log.AddLogger(ConfigLogger{LogWriter: audit, Level: INFO, Formatter: NewPatFormatter("%M"),
+		Filter: ExtraEquals("audit", true)})
+	log.AddLogger(ConfigLogger{LogWriter: console, Level: INFO, Formatter: NewPatFormatter("%M"),
+		Filter: And(MessageExcludes(regexp.MustCompile(`^health`)), Not(HasExtra("audit")))})
+	log.AddLogger(ConfigLogger{LogWriter: other, Level: INFO, Formatter: NewPatFormatter("%M"),
+		Filter: Or(SourceMatches("nothing_*.go"), And(SourceMatches("timber_*.go"), HostnameMatches("db-*")),
+			ExtraEquals("user", "42"))})
+
+	log.InfoEx(map[string]interface{}{"audit": true}, "login")
+	log.InfoEx(map[string]interface{}{"audit": false}, "not audited")
+	log.Info("health check")
+	log.InfoEx(map[string]interface{}{"user": 42}, "hello")
+	log.Close()
+
+	a.Equal([]string{"login\n"}, audit.logs)
+	a.Equal([]string{"hello\n"}, console.logs)
+	a.Equal([]string{"hello\n"}, other.logs)
+
+	dir := t.TempDir()
+	configs := map[string]string{
+		"timber.xml": `<logging><filter enabled="true"><type>file</type><level>INFO</level>
+			<match any="true"><extra key="audit" value="true"/>
+				<match><notmessage>^health</notmessage><hostname>web-*</hostname><source>*.go</source><extra key="user"/></match></match>
+			<property name="filename">` + dir + `/xml.log</property><property name="format">%M</property></filter></logging>`,
+		"timber.json": `{"filters": [{"enabled": true, "type": "file", "level": "INFO",
+			"match": {"any": true, "extras": [{"key": "audit", "value": "true"}],
+				"matches": [{"notMessages": ["^health"], "hostnames": ["web-*"], "sources": ["*.go"], "extras": [{"key": "user"}]}]},
+			"properties": [{"name": "filename", "value": "` + dir + `/json.log"}, {"name": "format", "value": "%M"}]}]}`,
+	}
+	for name, config := range configs {
+		a.Nil(os.WriteFile(dir+"/"+name, []byte(config), 0644))
+		log := NewTimber()
+		log.Hostname = func() string { return "web-1" }
+		a.Nil(log.LoadConfig(dir+"/"+name), name)
+		log.InfoEx(map[string]interface{}{"audit": true}, "login")
+		log.InfoEx(map[string]interface{}{"audit": false}, "not audited")
+		log.InfoEx(map[string]interface{}{"user": 42}, "health check")
+		log.InfoEx(map[string]interface{}{"user": 42}, "hello")
+		log.Close()
+
+		out, err := os.ReadFile(dir + "/" + name[len("timber."):] + ".log")
+		a.Nil(err, name)
+		a.Equal("login\nhello\n", string(out), name)
+	}
+
+	bad := map[string]string{
+		"bad.xml":  `<logging><filter enabled="true"><tag>typo</tag><type>console</type><match><message>(</message></match></filter></logging>`,
+		"bad.json": `{"filters": [{"enabled": true, "tag": "typo", "type": "console", "match": {"matches": [{"sources": ["["]}]}}]}`,
+	}
+	for name, config := range bad {
+		a.Nil(os.WriteFile(dir+"/"+name, []byte(config), 0644))
+		log := NewTimber()
+		err := log.LoadConfig(dir + "/" + name)
+		if a.NotNil(err, name) {
+			a.Contains(err.Error(), `"typo"`, name)
+		}
+		log.Close()
+	}
+}